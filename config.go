@@ -3,11 +3,15 @@ package lightstepreceiver
 import (
 	"go.opentelemetry.io/collector/config/configgrpc"
 	"go.opentelemetry.io/collector/config/confighttp"
+
+	lightstepCommon "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_common"
 )
 
 // Config represents Lightstep receiver configuration, follows the OTLP stype
 type Config struct {
 	Protocols `mapstructure:"protocols"`
+
+	lightstepCommon.TransformConfig `mapstructure:",squash"`
 }
 
 // Protocols represents supported protocols
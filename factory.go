@@ -11,6 +11,7 @@ import (
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/receiver"
 
+	lightstepCommon "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_common"
 	"github.com/zalando/otelcol-lightstep-receiver/internal/metadata"
 )
 
@@ -26,7 +27,9 @@ func NewFactory() receiver.Factory {
 	return receiver.NewFactory(
 		cfgType,
 		createDefaultConfig,
-		receiver.WithTraces(createLightstepReceiver, component.StabilityLevelDevelopment),
+		receiver.WithTraces(createTracesReceiver, component.StabilityLevelDevelopment),
+		receiver.WithMetrics(createMetricsReceiver, component.StabilityLevelDevelopment),
+		receiver.WithLogs(createLogsReceiver, component.StabilityLevelDevelopment),
 	)
 }
 
@@ -53,11 +56,78 @@ func createDefaultConfig() component.Config {
 				},
 			},
 		},
+		TransformConfig: lightstepCommon.TransformConfig{
+			Baggage: lightstepCommon.BaggageConfig{
+				Mode:   lightstepCommon.BaggageModeOff,
+				Prefix: "baggage.",
+			},
+			TraceID: lightstepCommon.TraceIDConfig{
+				UpperTag: "lightstep.trace_id_upper",
+			},
+			JSONValue: lightstepCommon.JSONValueConfig{
+				MaxDepth: 10,
+				MaxSize:  64 * 1024,
+			},
+			JoinIDs: lightstepCommon.JoinIDsConfig{
+				Namespace: "join_id.",
+			},
+			NonUTF8: lightstepCommon.NonUTF8Config{
+				Mode:         lightstepCommon.NonUTF8ModeDrop,
+				Base64Suffix: ";base64",
+			},
+			LightstepTags: lightstepCommon.LightstepTagsConfig{
+				Mode: lightstepCommon.LightstepTagsModeOff,
+			},
+			Runtime: lightstepCommon.RuntimeConfig{
+				GroupNameAttribute: "lightstep.group_name",
+			},
+			SpanKind: lightstepCommon.SpanKindConfig{
+				CustomKind: lightstepCommon.CustomKindUnknown,
+			},
+			Status: lightstepCommon.StatusConfig{
+				MessageTags: []string{"error.message", "error.msg"},
+			},
+			Validation: lightstepCommon.ValidationConfig{
+				ZeroTraceID:      lightstepCommon.ValidationActionOff,
+				ZeroSpanID:       lightstepCommon.ValidationActionOff,
+				SelfParent:       lightstepCommon.ValidationActionOff,
+				MissingTimestamp: lightstepCommon.ValidationActionOff,
+				EndBeforeStart:   lightstepCommon.ValidationActionOff,
+				EmptyName:        lightstepCommon.ValidationActionOff,
+			},
+		},
 	}
 }
 
-func createLightstepReceiver(ctx context.Context, set receiver.Settings, config component.Config, nextConsumer consumer.Traces) (receiver.Traces, error) {
+func createTracesReceiver(ctx context.Context, set receiver.Settings, config component.Config, nextConsumer consumer.Traces) (receiver.Traces, error) {
 	cfg := config.(*Config)
 
-	return newLightstepReceiver(cfg, &set, nextConsumer)
+	r, err := receivers.getOrCreate(cfg, &set)
+	if err != nil {
+		return nil, err
+	}
+	r.nextTraces = nextConsumer
+	return r, nil
+}
+
+func createMetricsReceiver(ctx context.Context, set receiver.Settings, config component.Config, nextConsumer consumer.Metrics) (receiver.Metrics, error) {
+	cfg := config.(*Config)
+
+	r, err := receivers.getOrCreate(cfg, &set)
+	if err != nil {
+		return nil, err
+	}
+	r.nextMetrics = nextConsumer
+	return r, nil
+}
+
+func createLogsReceiver(ctx context.Context, set receiver.Settings, config component.Config, nextConsumer consumer.Logs) (receiver.Logs, error) {
+	cfg := config.(*Config)
+
+	r, err := receivers.getOrCreate(cfg, &set)
+	if err != nil {
+		return nil, err
+	}
+	r.nextLogs = nextConsumer
+	return r, nil
 }
@@ -0,0 +1,146 @@
+package lightstep_common
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// AttributeType is a target type of attribute coercion
+type AttributeType string
+
+const (
+	AttributeTypeString AttributeType = "string"
+	AttributeTypeInt    AttributeType = "int"
+	AttributeTypeDouble AttributeType = "double"
+	AttributeTypeBool   AttributeType = "bool"
+)
+
+// TypeCoercionConfig represents settings of attribute type coercion
+type TypeCoercionConfig struct {
+	// Infer enables heuristic int, double and bool inference of string attributes of string-only formats (thrift)
+	Infer bool `mapstructure:"infer"`
+	// Schema defines the type of attributes by key, applies to all the formats and takes precedence over inference
+	Schema map[string]AttributeType `mapstructure:"schema"`
+}
+
+// Validate checks the type coercion settings
+func (c *TypeCoercionConfig) Validate() error {
+	for k, t := range c.Schema {
+		switch t {
+		case AttributeTypeString, AttributeTypeInt, AttributeTypeDouble, AttributeTypeBool:
+		default:
+			return fmt.Errorf("unknown type %q of attribute %q", t, k)
+		}
+	}
+	return nil
+}
+
+// Coerce converts attributes to the types defined by schema, with infer set remaining string attributes
+// are converted to int, double or bool when they look like one
+func (c *TypeCoercionConfig) Coerce(attr pcommon.Map, infer bool) {
+	if len(c.Schema) == 0 && !infer {
+		return
+	}
+	attr.Range(func(k string, v pcommon.Value) bool {
+		if t, ok := c.Schema[k]; ok {
+			coerce(v, t)
+		} else if infer && v.Type() == pcommon.ValueTypeStr {
+			inferType(v)
+		}
+		return true
+	})
+}
+
+func coerce(v pcommon.Value, t AttributeType) {
+	switch t {
+	case AttributeTypeString:
+		if v.Type() != pcommon.ValueTypeStr {
+			v.SetStr(v.AsString())
+		}
+	case AttributeTypeInt:
+		switch v.Type() {
+		case pcommon.ValueTypeStr:
+			s := strings.TrimSpace(v.Str())
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				v.SetInt(i)
+			} else if d, err := strconv.ParseFloat(s, 64); err == nil && isIntegral(d) {
+				v.SetInt(int64(d))
+			}
+		case pcommon.ValueTypeDouble:
+			if d := v.Double(); isIntegral(d) {
+				v.SetInt(int64(d))
+			}
+		case pcommon.ValueTypeBool:
+			if v.Bool() {
+				v.SetInt(1)
+			} else {
+				v.SetInt(0)
+			}
+		}
+	case AttributeTypeDouble:
+		switch v.Type() {
+		case pcommon.ValueTypeStr:
+			if d, err := strconv.ParseFloat(strings.TrimSpace(v.Str()), 64); err == nil {
+				v.SetDouble(d)
+			}
+		case pcommon.ValueTypeInt:
+			v.SetDouble(float64(v.Int()))
+		}
+	case AttributeTypeBool:
+		switch v.Type() {
+		case pcommon.ValueTypeStr:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v.Str())); err == nil {
+				v.SetBool(b)
+			}
+		case pcommon.ValueTypeInt:
+			v.SetBool(v.Int() != 0)
+		}
+	}
+}
+
+// inferType converts canonical string representations only, so ids with leading zeros or hex values stay strings
+func inferType(v pcommon.Value) {
+	s := v.Str()
+	switch s {
+	case "true":
+		v.SetBool(true)
+		return
+	case "false":
+		v.SetBool(false)
+		return
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s {
+		v.SetInt(i)
+		return
+	}
+
+	if !strings.Contains(s, ".") || strings.HasPrefix(s, ".") || strings.HasSuffix(s, ".") {
+		return
+	}
+	if d, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(d, 0) && !math.IsNaN(d) && isDecimal(s) {
+		v.SetDouble(d)
+	}
+}
+
+func isIntegral(d float64) bool {
+	return d == math.Trunc(d) && !math.IsInf(d, 0) && math.Abs(d) < math.MaxInt64
+}
+
+func isDecimal(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	intPart, _, _ := strings.Cut(s, ".")
+	if len(intPart) > 1 && intPart[0] == '0' {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	return true
+}
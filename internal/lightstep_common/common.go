@@ -1,10 +1,15 @@
 package lightstep_common
 
 import (
+	"encoding/binary"
 	"errors"
+	"strconv"
 	"strings"
+	"time"
 
 	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
@@ -15,6 +20,23 @@ var (
 	ErrNoServiceName = errors.New("missing service.name (lightstep.component_name)")
 	// ErrNonUTF8Attribute happens if there's an attribute containing non UTF8 string
 	ErrNonUTF8Attribute = errors.New("attribute is not UTF8 string")
+	// ErrNoReportRequest happens when the payload carries no report request at all
+	ErrNoReportRequest = errors.New("missing report request")
+)
+
+const (
+	// RefTypeAttribute keeps the original OpenTracing reference type on span links
+	RefTypeAttribute = "opentracing.ref_type"
+	// RefTypeChildOf is the RefTypeAttribute value for CHILD_OF references
+	RefTypeChildOf = "child_of"
+	// RefTypeFollowsFrom is the RefTypeAttribute value for FOLLOWS_FROM references
+	RefTypeFollowsFrom = "follows_from"
+	// JoinKeyAttribute keeps the join id key of span links made of thrift trace join ids
+	JoinKeyAttribute = "lightstep.join_key"
+	// ClockCorrectionAttribute marks spans with adjusted timestamps, keeps the applied offset in microseconds
+	ClockCorrectionAttribute = "lightstep.timestamp_offset_micros"
+	// ReportingRuntimeAttribute marks resources of spans batched from other runtimes, keeps the reporting runtime guid
+	ReportingRuntimeAttribute = "lightstep.reporting_runtime_guid"
 )
 
 // ProjectTraces contains Traces in Otel format and access token
@@ -22,7 +44,14 @@ type ProjectTraces struct {
 	AccessToken        string
 	ServiceName        string
 	ClientSpansDropped int64
+	// OrphanLogRecords counts report log records not attached to spans of the report, kept by Logs only
+	OrphanLogRecords int64
 	ptrace.Traces
+
+	// Metrics keeps tracer client side metrics
+	Metrics pmetric.Metrics
+	// Logs keeps log records reported by tracer
+	Logs plog.Logs
 }
 
 type OtelTransformer interface {
@@ -40,20 +69,54 @@ func IsErrorAttributeValueActuallyError(value pcommon.Value) bool {
 	}
 }
 
-func ParseSpanKindAttributeValue(value pcommon.Value) (ptrace.SpanKind, string) {
-	stringValue := value.AsString()
-	switch strings.ToLower(stringValue) {
-	case "internal":
-		return ptrace.SpanKindInternal, stringValue
-	case "server":
-		return ptrace.SpanKindServer, stringValue
-	case "client":
-		return ptrace.SpanKindClient, stringValue
-	case "producer":
-		return ptrace.SpanKindProducer, stringValue
-	case "consumer":
-		return ptrace.SpanKindConsumer, stringValue
-	default:
-		return ptrace.SpanKindUnspecified, stringValue
+// ApplyClockCorrection shifts span and its events timestamps by offset marking the span as adjusted
+func ApplyClockCorrection(span ptrace.Span, offset time.Duration) {
+	if offset == 0 {
+		return
+	}
+	span.SetStartTimestamp(shiftTimestamp(span.StartTimestamp(), offset))
+	span.SetEndTimestamp(shiftTimestamp(span.EndTimestamp(), offset))
+	for i := 0; i < span.Events().Len(); i++ {
+		ev := span.Events().At(i)
+		ev.SetTimestamp(shiftTimestamp(ev.Timestamp(), offset))
+	}
+	span.Attributes().PutInt(ClockCorrectionAttribute, offset.Microseconds())
+}
+
+func shiftTimestamp(ts pcommon.Timestamp, offset time.Duration) pcommon.Timestamp {
+	// unset timestamps stay unset
+	if ts == 0 {
+		return ts
+	}
+	return pcommon.NewTimestampFromTime(ts.AsTime().Add(offset))
+}
+
+// NewTraceID builds 128-bit trace id out of its upper and lower halves
+func NewTraceID(upper, lower uint64) pcommon.TraceID {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], upper)
+	binary.BigEndian.PutUint64(b[8:], lower)
+	return b
+}
+
+// WithTraceIDUpper sets the upper half of the trace id if it's not set yet
+func WithTraceIDUpper(id pcommon.TraceID, upper uint64) pcommon.TraceID {
+	if upper == 0 || binary.BigEndian.Uint64(id[:8]) != 0 {
+		return id
+	}
+	binary.BigEndian.PutUint64(id[:8], upper)
+	return id
+}
+
+// ParseTraceIDUpper parses the upper half of the trace id reported as hex string tag
+func ParseTraceIDUpper(v string) (uint64, bool) {
+	v = strings.TrimPrefix(strings.ToLower(v), "0x")
+	if v == "" || len(v) > 16 {
+		return 0, false
+	}
+	res, err := strconv.ParseUint(v, 16, 64)
+	if err != nil {
+		return 0, false
 	}
+	return res, true
 }
@@ -0,0 +1,159 @@
+package lightstep_common
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// TransformConfig keeps settings applied while transforming Lightstep payloads into Otel
+type TransformConfig struct {
+	Baggage         BaggageConfig         `mapstructure:"baggage"`
+	ClockCorrection ClockCorrectionConfig `mapstructure:"clock_correction"`
+	TraceID         TraceIDConfig         `mapstructure:"trace_id"`
+	JSONValue       JSONValueConfig       `mapstructure:"json_value"`
+	SemConv         SemConvConfig         `mapstructure:"semconv"`
+	Logs            LogsConfig            `mapstructure:"logs"`
+	JoinIDs         JoinIDsConfig         `mapstructure:"join_ids"`
+	TypeCoercion    TypeCoercionConfig    `mapstructure:"type_coercion"`
+	NonUTF8         NonUTF8Config         `mapstructure:"non_utf8"`
+	LightstepTags   LightstepTagsConfig   `mapstructure:"lightstep_tags"`
+	Runtime         RuntimeConfig         `mapstructure:"runtime"`
+	ServiceOverride ServiceOverrideConfig `mapstructure:"service_override"`
+	SpanKind        SpanKindConfig        `mapstructure:"span_kind"`
+	Status          StatusConfig          `mapstructure:"status"`
+	Validation      ValidationConfig      `mapstructure:"validation"`
+}
+
+// BaggageMode defines how SpanContext baggage is carried into span attributes
+type BaggageMode string
+
+const (
+	// BaggageModeOff drops baggage items
+	BaggageModeOff BaggageMode = "off"
+	// BaggageModeAll copies every baggage item
+	BaggageModeAll BaggageMode = "all"
+	// BaggageModeAllowlist copies only baggage items listed in BaggageConfig.Keys
+	BaggageModeAllowlist BaggageMode = "allowlist"
+)
+
+// BaggageConfig represents baggage conversion settings
+type BaggageConfig struct {
+	Mode   BaggageMode `mapstructure:"mode"`
+	Prefix string      `mapstructure:"prefix"`
+	Keys   []string    `mapstructure:"keys"`
+}
+
+// Validate checks the baggage settings
+func (c *BaggageConfig) Validate() error {
+	switch c.Mode {
+	case "", BaggageModeOff, BaggageModeAll:
+		return nil
+	case BaggageModeAllowlist:
+		if len(c.Keys) == 0 {
+			return fmt.Errorf("baggage mode %q requires keys", c.Mode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown baggage mode %q", c.Mode)
+	}
+}
+
+// CopyBaggage puts baggage items into attributes according to the configured mode
+func (c *BaggageConfig) CopyBaggage(baggage map[string]string, attr pcommon.Map) {
+	if c.Mode != BaggageModeAll && c.Mode != BaggageModeAllowlist {
+		return
+	}
+
+	keys := make([]string, 0, len(baggage))
+	for k := range baggage {
+		if c.Mode == BaggageModeAllowlist && !slices.Contains(c.Keys, k) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		attr.PutStr(c.Prefix+k, baggage[k])
+	}
+}
+
+// ClockCorrectionConfig represents settings of applying the tracer reported timestamp_offset_micros
+type ClockCorrectionConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	MaxOffset time.Duration `mapstructure:"max_offset"`
+}
+
+// Validate checks the clock correction settings
+func (c *ClockCorrectionConfig) Validate() error {
+	if c.MaxOffset < 0 {
+		return errors.New("clock_correction max_offset can't be negative")
+	}
+	return nil
+}
+
+// Offset returns the correction to apply for the reported offset, capped by MaxOffset
+func (c *ClockCorrectionConfig) Offset(offsetMicros int64) time.Duration {
+	if !c.Enabled || offsetMicros == 0 {
+		return 0
+	}
+	offset := time.Duration(offsetMicros) * time.Microsecond
+	if c.MaxOffset > 0 {
+		offset = max(-c.MaxOffset, min(offset, c.MaxOffset))
+	}
+	return offset
+}
+
+// TraceIDConfig represents settings of recovering 128-bit trace ids
+type TraceIDConfig struct {
+	// UpperTag is the span tag holding the upper 64 bits of the trace id, empty disables the lookup
+	UpperTag string `mapstructure:"upper_tag"`
+}
+
+// RemoveTraceIDUpperTag removes the upper trace id tag from span attributes, including the key it's passed
+// through under by the lightstep tags settings
+func (c *TransformConfig) RemoveTraceIDUpperTag(attr pcommon.Map) {
+	if c.TraceID.UpperTag == "" {
+		return
+	}
+	attr.Remove(c.TraceID.UpperTag)
+	if key, ok := c.LightstepTags.AttributeKey(c.TraceID.UpperTag, false); ok {
+		attr.Remove(key)
+	}
+}
+
+// JSONValueConfig represents settings of converting json_value tags into structured attributes
+type JSONValueConfig struct {
+	Parse bool `mapstructure:"parse"`
+	// MaxDepth limits nesting of parsed values, 0 means no limit
+	MaxDepth int `mapstructure:"max_depth"`
+	// MaxSize limits the length in bytes of parsed values, 0 means no limit
+	MaxSize int `mapstructure:"max_size"`
+}
+
+// Validate checks the json value settings
+func (c *JSONValueConfig) Validate() error {
+	if c.MaxDepth < 0 || c.MaxSize < 0 {
+		return errors.New("json_value limits can't be negative")
+	}
+	return nil
+}
+
+// LogsConfig represents settings of the logs signal
+type LogsConfig struct {
+	// SpanEvents copies span logs as log records correlated with their spans
+	SpanEvents bool `mapstructure:"span_events"`
+}
+
+// JoinIDsConfig represents settings of converting thrift trace join ids
+type JoinIDsConfig struct {
+	// Namespace is prepended to join id keys to form span attribute keys
+	Namespace string `mapstructure:"namespace"`
+	// LinkKeys are join id keys holding span contexts of correlated spans, these are converted into span links
+	LinkKeys []string `mapstructure:"link_keys"`
+}
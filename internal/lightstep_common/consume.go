@@ -0,0 +1,32 @@
+package lightstep_common
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+)
+
+// ConsumeMetrics passes tracer metrics to the next consumer, does nothing if there's no metrics pipeline or no data points
+func ConsumeMetrics(ctx context.Context, next consumer.Metrics, obsreport *receiverhelper.ObsReport, format string, metrics pmetric.Metrics) error {
+	if next == nil || metrics.DataPointCount() == 0 {
+		return nil
+	}
+	ctx = obsreport.StartMetricsOp(ctx)
+	err := next.ConsumeMetrics(ctx, metrics)
+	obsreport.EndMetricsOp(ctx, format, metrics.DataPointCount(), err)
+	return err
+}
+
+// ConsumeLogs passes tracer logs to the next consumer, does nothing if there's no logs pipeline or no log records
+func ConsumeLogs(ctx context.Context, next consumer.Logs, obsreport *receiverhelper.ObsReport, format string, logs plog.Logs) error {
+	if next == nil || logs.LogRecordCount() == 0 {
+		return nil
+	}
+	ctx = obsreport.StartLogsOp(ctx)
+	err := next.ConsumeLogs(ctx, logs)
+	obsreport.EndLogsOp(ctx, format, logs.LogRecordCount(), err)
+	return err
+}
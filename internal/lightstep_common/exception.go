@@ -0,0 +1,73 @@
+package lightstep_common
+
+import (
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Otel exception event and the OpenTracing error log fields it's made of
+const (
+	ExceptionEventName           = "exception"
+	ExceptionTypeAttribute       = "exception.type"
+	ExceptionMessageAttribute    = "exception.message"
+	ExceptionStacktraceAttribute = "exception.stacktrace"
+
+	errorEventName       = "error"
+	errorKindField       = "error.kind"
+	errorObjectField     = "error.object"
+	errorMessageField    = "message"
+	errorStacktraceField = "stack"
+)
+
+// ConvertErrorEvent rewrites OpenTracing error log events (event=error) into Otel exception events, mapping
+// error.kind, message (or error.object) and stack fields onto exception attributes not set yet. Mapped fields
+// are removed, as are fields duplicating an exception attribute, the ones differing from it are kept
+func ConvertErrorEvent(ev ptrace.SpanEvent) {
+	if ev.Name() != errorEventName && ev.Name() != ExceptionEventName {
+		return
+	}
+	ev.SetName(ExceptionEventName)
+
+	attr := ev.Attributes()
+	mapped := make(map[string]bool)
+	for _, m := range []struct{ from, to string }{
+		{errorKindField, ExceptionTypeAttribute},
+		{errorMessageField, ExceptionMessageAttribute},
+		{errorObjectField, ExceptionMessageAttribute},
+		{errorStacktraceField, ExceptionStacktraceAttribute},
+	} {
+		v, ok := attr.Get(m.from)
+		if !ok {
+			continue
+		}
+		value := v.AsString()
+		if existing, exists := attr.Get(m.to); !exists {
+			attr.PutStr(m.to, value)
+			mapped[m.to] = true
+		} else if !mapped[m.to] && existing.AsString() != value {
+			continue
+		}
+		attr.Remove(m.from)
+	}
+}
+
+// ExceptionMessage returns the message of the first exception event of the span
+func ExceptionMessage(s ptrace.Span) string {
+	for i := 0; i < s.Events().Len(); i++ {
+		ev := s.Events().At(i)
+		if ev.Name() != ExceptionEventName {
+			continue
+		}
+		if v, ok := ev.Attributes().Get(ExceptionMessageAttribute); ok && v.AsString() != "" {
+			return v.AsString()
+		}
+	}
+	return ""
+}
+
+// SetExceptionStatusMessage fills the empty status message of error spans with the first exception message
+func SetExceptionStatusMessage(s ptrace.Span) {
+	if s.Status().Code() != ptrace.StatusCodeError || s.Status().Message() != "" {
+		return
+	}
+	s.Status().SetMessage(ExceptionMessage(s))
+}
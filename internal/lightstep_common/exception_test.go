@@ -0,0 +1,71 @@
+package lightstep_common
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestConvertErrorEvent(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		event    string
+		attrs    map[string]any
+		expected map[string]any
+	}{
+		{
+			name:  "error fields",
+			event: "error",
+			attrs: map[string]any{"error.kind": "ValueError", "message": "invalid literal", "stack": "main.py:1"},
+			expected: map[string]any{
+				"exception.type":       "ValueError",
+				"exception.message":    "invalid literal",
+				"exception.stacktrace": "main.py:1",
+			},
+		},
+		{
+			name:     "error object fallback",
+			event:    "error",
+			attrs:    map[string]any{"error.object": "boom"},
+			expected: map[string]any{"exception.message": "boom"},
+		},
+		{
+			name:     "message and error object",
+			event:    "error",
+			attrs:    map[string]any{"message": "invalid literal", "error.object": "ValueError: invalid literal"},
+			expected: map[string]any{"exception.message": "invalid literal"},
+		},
+		{
+			name:     "duplicate of exception attribute",
+			event:    "exception",
+			attrs:    map[string]any{"exception.message": "invalid literal", "message": "invalid literal"},
+			expected: map[string]any{"exception.message": "invalid literal"},
+		},
+		{
+			name:     "differing from exception attribute",
+			event:    "exception",
+			attrs:    map[string]any{"exception.message": "invalid literal", "message": "retrying"},
+			expected: map[string]any{"exception.message": "invalid literal", "message": "retrying"},
+		},
+		{
+			name:     "not an error",
+			event:    "cache-miss",
+			attrs:    map[string]any{"message": "key not found"},
+			expected: map[string]any{"message": "key not found"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			ev := ptrace.NewSpanEvent()
+			ev.SetName(tc.event)
+			is.NoErr(ev.Attributes().FromRaw(tc.attrs))
+
+			ConvertErrorEvent(ev)
+			if tc.event != "cache-miss" {
+				is.Equal(ev.Name(), ExceptionEventName)
+			}
+			is.Equal(ev.Attributes().AsRaw(), tc.expected)
+		})
+	}
+}
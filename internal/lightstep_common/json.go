@@ -0,0 +1,88 @@
+package lightstep_common
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+var errJSONTooDeep = errors.New("json value is too deep")
+
+// PutJSONValue puts json encoded value into attributes, objects and arrays are converted
+// into maps and slices when enabled, anything else is kept as string
+func (c *JSONValueConfig) PutJSONValue(m pcommon.Map, key string, raw string) {
+	if !c.Parse || (c.MaxSize > 0 && len(raw) > c.MaxSize) {
+		m.PutStr(key, raw)
+		return
+	}
+
+	trimmed := strings.TrimSpace(raw)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		m.PutStr(key, raw)
+		return
+	}
+
+	var decoded any
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil || dec.More() {
+		m.PutStr(key, raw)
+		return
+	}
+
+	v := pcommon.NewValueEmpty()
+	if err := c.fromJSON(decoded, v, 1); err != nil {
+		m.PutStr(key, raw)
+		return
+	}
+	v.CopyTo(m.PutEmpty(key))
+}
+
+func (c *JSONValueConfig) fromJSON(decoded any, v pcommon.Value, depth int) error {
+	switch d := decoded.(type) {
+	case map[string]any:
+		if c.MaxDepth > 0 && depth > c.MaxDepth {
+			return errJSONTooDeep
+		}
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		m := v.SetEmptyMap()
+		m.EnsureCapacity(len(d))
+		for _, k := range keys {
+			if err := c.fromJSON(d[k], m.PutEmpty(k), depth+1); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if c.MaxDepth > 0 && depth > c.MaxDepth {
+			return errJSONTooDeep
+		}
+		s := v.SetEmptySlice()
+		s.EnsureCapacity(len(d))
+		for _, item := range d {
+			if err := c.fromJSON(item, s.AppendEmpty(), depth+1); err != nil {
+				return err
+			}
+		}
+	case json.Number:
+		if i, err := d.Int64(); err == nil {
+			v.SetInt(i)
+		} else if f, err := d.Float64(); err == nil {
+			v.SetDouble(f)
+		} else {
+			v.SetStr(d.String())
+		}
+	case string:
+		v.SetStr(d)
+	case bool:
+		v.SetBool(d)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package lightstep_common
+
+import (
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// InternalLogAttribute marks log records describing the tracer itself
+const InternalLogAttribute = "lightstep.internal_log"
+
+// TracerLogs collects log records reported by tracers into Otel logs
+type TracerLogs struct {
+	logs  plog.Logs
+	scope plog.ScopeLogs
+}
+
+// NewTracerLogs creates TracerLogs of a reporter described by resource attributes
+func NewTracerLogs(resource pcommon.Map) *TracerLogs {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	resource.CopyTo(rl.Resource().Attributes())
+
+	return &TracerLogs{
+		logs:  logs,
+		scope: rl.ScopeLogs().AppendEmpty(),
+	}
+}
+
+// AppendEmpty appends empty log record
+func (l *TracerLogs) AppendEmpty() plog.LogRecord {
+	return l.scope.LogRecords().AppendEmpty()
+}
+
+// ApplyClockCorrection shifts timestamps of the log records collected so far by offset marking them as adjusted,
+// span events copied afterwards are shifted along with their spans
+func (l *TracerLogs) ApplyClockCorrection(offset time.Duration) {
+	if offset == 0 {
+		return
+	}
+	for i := 0; i < l.scope.LogRecords().Len(); i++ {
+		lr := l.scope.LogRecords().At(i)
+		lr.SetTimestamp(shiftTimestamp(lr.Timestamp(), offset))
+		lr.Attributes().PutInt(ClockCorrectionAttribute, offset.Microseconds())
+	}
+}
+
+// AppendSpanEvents copies span events as log records correlated with the span
+func (l *TracerLogs) AppendSpanEvents(span ptrace.Span) {
+	for i := 0; i < span.Events().Len(); i++ {
+		ev := span.Events().At(i)
+		lr := l.AppendEmpty()
+		lr.SetTimestamp(ev.Timestamp())
+		lr.SetTraceID(span.TraceID())
+		lr.SetSpanID(span.SpanID())
+		lr.SetEventName(ev.Name())
+		if ev.Name() != "" {
+			lr.Body().SetStr(ev.Name())
+		}
+		ev.Attributes().CopyTo(lr.Attributes())
+	}
+}
+
+// Logs returns collected logs, empty if there were no log records
+func (l *TracerLogs) Logs() plog.Logs {
+	if l.scope.LogRecords().Len() == 0 {
+		return plog.NewLogs()
+	}
+	return l.logs
+}
+
+// ParseSeverity maps Lightstep log levels onto Otel severity
+func ParseSeverity(level string) plog.SeverityNumber {
+	switch strings.ToLower(level) {
+	case "t", "trace":
+		return plog.SeverityNumberTrace
+	case "d", "debug":
+		return plog.SeverityNumberDebug
+	case "i", "info":
+		return plog.SeverityNumberInfo
+	case "w", "warn", "warning":
+		return plog.SeverityNumberWarn
+	case "e", "error":
+		return plog.SeverityNumberError
+	case "f", "fatal":
+		return plog.SeverityNumberFatal
+	default:
+		return plog.SeverityNumberUnspecified
+	}
+}
@@ -0,0 +1,80 @@
+package lightstep_common
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// TracerMetricPrefix is prepended to the names of metrics reported by tracers
+const TracerMetricPrefix = "lightstep.tracer."
+
+// TracerMetrics collects tracer client side metric samples into Otel metrics
+type TracerMetrics struct {
+	metrics pmetric.Metrics
+	scope   pmetric.ScopeMetrics
+	start   pcommon.Timestamp
+	end     pcommon.Timestamp
+}
+
+// NewTracerMetrics creates TracerMetrics of a reporter described by resource attributes, for samples collected between start and end
+func NewTracerMetrics(resource pcommon.Map, start, end pcommon.Timestamp) *TracerMetrics {
+	metrics := pmetric.NewMetrics()
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	resource.CopyTo(rm.Resource().Attributes())
+
+	return &TracerMetrics{
+		metrics: metrics,
+		scope:   rm.ScopeMetrics().AppendEmpty(),
+		start:   start,
+		end:     end,
+	}
+}
+
+func (m *TracerMetrics) appendSum(name string) pmetric.NumberDataPoint {
+	metric := m.scope.Metrics().AppendEmpty()
+	metric.SetName(TracerMetricPrefix + name)
+	metric.SetUnit("1")
+	sum := metric.SetEmptySum()
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	sum.SetIsMonotonic(true)
+	dp := sum.DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(m.start)
+	dp.SetTimestamp(m.end)
+	return dp
+}
+
+func (m *TracerMetrics) appendGauge(name string) pmetric.NumberDataPoint {
+	metric := m.scope.Metrics().AppendEmpty()
+	metric.SetName(TracerMetricPrefix + name)
+	dp := metric.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(m.end)
+	return dp
+}
+
+// AddCountInt adds delta counter sample
+func (m *TracerMetrics) AddCountInt(name string, value int64) {
+	m.appendSum(name).SetIntValue(value)
+}
+
+// AddCountDouble adds delta counter sample
+func (m *TracerMetrics) AddCountDouble(name string, value float64) {
+	m.appendSum(name).SetDoubleValue(value)
+}
+
+// AddGaugeInt adds gauge sample
+func (m *TracerMetrics) AddGaugeInt(name string, value int64) {
+	m.appendGauge(name).SetIntValue(value)
+}
+
+// AddGaugeDouble adds gauge sample
+func (m *TracerMetrics) AddGaugeDouble(name string, value float64) {
+	m.appendGauge(name).SetDoubleValue(value)
+}
+
+// Metrics returns collected metrics, empty if there were no samples
+func (m *TracerMetrics) Metrics() pmetric.Metrics {
+	if m.scope.Metrics().Len() == 0 {
+		return pmetric.NewMetrics()
+	}
+	return m.metrics
+}
@@ -0,0 +1,54 @@
+package lightstep_common
+
+import (
+	"time"
+
+	lightstepConstants "github.com/lightstep/lightstep-tracer-go/constants"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+const (
+	// ServiceInstanceIDAttribute keeps the reporter id or thrift runtime guid
+	ServiceInstanceIDAttribute = "service.instance.id"
+	// ProcessCreationTimeAttribute keeps the thrift runtime start time
+	ProcessCreationTimeAttribute = "process.creation.time"
+	// ServiceNameAttribute keeps the lightstep component name
+	ServiceNameAttribute = "service.name"
+	// UnknownServiceName is the service name of resources without component name
+	UnknownServiceName = "unknown_service"
+)
+
+// resourceTagKeys maps well-known lightstep reporter tags onto Otel resource attribute keys
+var resourceTagKeys = map[string]string{
+	lightstepConstants.HostnameKey:      "host.name",
+	"lightstep.guid":                    ServiceInstanceIDAttribute,
+	"lightstep.tracer_platform":         "telemetry.sdk.language",
+	"lightstep.tracer_platform_version": "process.runtime.version",
+	"lightstep.tracer_version":          "telemetry.sdk.version",
+}
+
+// ResourceTagKey returns the resource attribute key of the component name and well-known lightstep reporter tags
+func ResourceTagKey(key string) (string, bool) {
+	if key == lightstepConstants.ComponentNameKey {
+		return ServiceNameAttribute, true
+	}
+	mapped, ok := resourceTagKeys[key]
+	return mapped, ok
+}
+
+// RuntimeConfig represents settings of mapping the reporter runtime onto resource attributes
+type RuntimeConfig struct {
+	// GroupNameAttribute is the resource attribute keeping thrift runtime group name, empty disables it
+	GroupNameAttribute string `mapstructure:"group_name_attribute"`
+}
+
+// PutRuntimeIdentity puts the reporter instance id and the process start time into resource attributes,
+// empty values are skipped and an instance id already set by reporter tags is kept
+func PutRuntimeIdentity(attr pcommon.Map, instanceID string, startMicros int64) {
+	if _, ok := attr.Get(ServiceInstanceIDAttribute); !ok && instanceID != "" {
+		attr.PutStr(ServiceInstanceIDAttribute, instanceID)
+	}
+	if startMicros > 0 {
+		attr.PutStr(ProcessCreationTimeAttribute, time.UnixMicro(startMicros).UTC().Format(time.RFC3339Nano))
+	}
+}
@@ -0,0 +1,129 @@
+package lightstep_common
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const schemaURLPrefix = "https://opentelemetry.io/schemas/"
+
+// semConvRulesets keeps OpenTracing to Otel semantic conventions renames by the target semconv version, peer tags
+// are renamed as seen by client spans
+var semConvRulesets = map[string]map[string]string{
+	"1.21.0": {
+		"http.url":                "url.full",
+		"http.method":             "http.request.method",
+		"http.status_code":        "http.response.status_code",
+		"peer.hostname":           "server.address",
+		"peer.port":               "server.port",
+		"peer.ipv4":               "network.peer.address",
+		"peer.ipv6":               "network.peer.address",
+		"db.type":                 "db.system",
+		"db.instance":             "db.name",
+		"message_bus.destination": "messaging.destination.name",
+	},
+	"1.26.0": {
+		"http.url":                "url.full",
+		"http.method":             "http.request.method",
+		"http.status_code":        "http.response.status_code",
+		"peer.hostname":           "server.address",
+		"peer.port":               "server.port",
+		"peer.ipv4":               "network.peer.address",
+		"peer.ipv6":               "network.peer.address",
+		"db.type":                 "db.system",
+		"db.instance":             "db.namespace",
+		"db.statement":            "db.query.text",
+		"message_bus.destination": "messaging.destination.name",
+	},
+}
+
+// serverSpanRenames override the ruleset renames of server spans, where the peer is the client
+var serverSpanRenames = map[string]string{
+	"peer.hostname": "client.address",
+	"peer.port":     "client.port",
+}
+
+// semConvValues translates OpenTracing values of renamed attributes into Otel ones, other values are kept
+var semConvValues = map[string]map[string]string{
+	"db.system": {"sql": "other_sql"},
+}
+
+// SemConvConfig represents settings of translating OpenTracing attributes into Otel semantic conventions
+type SemConvConfig struct {
+	// Version selects the built-in ruleset and the schema url, empty disables the built-in ruleset
+	Version string `mapstructure:"version"`
+	// Renames are applied on top of the built-in ruleset
+	Renames map[string]string `mapstructure:"renames"`
+}
+
+// Validate checks the semantic conventions settings
+func (c *SemConvConfig) Validate() error {
+	if _, ok := semConvRulesets[c.Version]; c.Version != "" && !ok {
+		return fmt.Errorf("unknown semconv version %q", c.Version)
+	}
+	for from, to := range c.Renames {
+		if from == "" || to == "" {
+			return fmt.Errorf("invalid semconv rename %q -> %q", from, to)
+		}
+	}
+	return nil
+}
+
+// SchemaURL returns the schema url of the configured semconv version
+func (c *SemConvConfig) SchemaURL() string {
+	if c.Version == "" {
+		return ""
+	}
+	return schemaURLPrefix + c.Version
+}
+
+func (c *SemConvConfig) rename(key string, kind ptrace.SpanKind) (string, bool) {
+	if to, ok := c.Renames[key]; ok {
+		return to, true
+	}
+	if to, ok := serverSpanRenames[key]; ok && c.Version != "" && kind == ptrace.SpanKindServer {
+		return to, true
+	}
+	to, ok := semConvRulesets[c.Version][key]
+	return to, ok
+}
+
+// TranslateSpan renames the span and span event attributes according to the configured rules
+func (c *SemConvConfig) TranslateSpan(s ptrace.Span) {
+	c.Translate(s.Attributes(), s.Kind())
+	for i := 0; i < s.Events().Len(); i++ {
+		c.Translate(s.Events().At(i).Attributes(), s.Kind())
+	}
+}
+
+// Translate renames attributes of a span of the given kind according to the configured rules, existing
+// attributes are never overwritten
+func (c *SemConvConfig) Translate(attr pcommon.Map, kind ptrace.SpanKind) {
+	if c.Version == "" && len(c.Renames) == 0 {
+		return
+	}
+
+	var keys []string
+	attr.Range(func(k string, _ pcommon.Value) bool {
+		if _, ok := c.rename(k, kind); ok {
+			keys = append(keys, k)
+		}
+		return true
+	})
+
+	for _, from := range keys {
+		to, _ := c.rename(from, kind)
+		if _, exists := attr.Get(to); exists {
+			continue
+		}
+		v, _ := attr.Get(from)
+		translated := attr.PutEmpty(to)
+		v.CopyTo(translated)
+		if value, ok := semConvValues[to][translated.Str()]; ok && translated.Type() == pcommon.ValueTypeStr {
+			translated.SetStr(value)
+		}
+		attr.Remove(from)
+	}
+}
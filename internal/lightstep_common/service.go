@@ -0,0 +1,88 @@
+package lightstep_common
+
+import (
+	lightstepConstants "github.com/lightstep/lightstep-tracer-go/constants"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// ServiceOverrideConfig represents settings of overriding the service of single spans by span tags
+type ServiceOverrideConfig struct {
+	// Tags lists span tags overriding service.name, the first one present wins
+	Tags []string `mapstructure:"tags"`
+	// StripTag removes the tag setting the service from span attributes, as its value is kept in service.name
+	StripTag bool `mapstructure:"strip_tag"`
+}
+
+// Apply moves spans with an override tag into their own ResourceSpans with the overridden service.name,
+// copying the other resource attributes and the schema url of the original ResourceSpans. ScopeSpans and
+// ResourceSpans left without spans are removed
+func (c *ServiceOverrideConfig) Apply(data ptrace.Traces) {
+	if len(c.Tags) == 0 {
+		return
+	}
+
+	for i, n := 0, data.ResourceSpans().Len(); i < n; i++ {
+		rs := data.ResourceSpans().At(i)
+		current := ""
+		if v, ok := rs.Resource().Attributes().Get(ServiceNameAttribute); ok {
+			current = v.AsString()
+		}
+
+		services := map[string]ptrace.ScopeSpans{}
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			if ss.Spans().Len() == 0 {
+				return false
+			}
+			ss.Spans().RemoveIf(func(s ptrace.Span) bool {
+				tag, service := c.service(s)
+				if service == "" {
+					return false
+				}
+				if c.StripTag {
+					s.Attributes().Remove(tag)
+				}
+				if service == current {
+					return false
+				}
+				target, ok := services[service]
+				if !ok {
+					target = newServiceResourceSpans(data, rs, ss, service)
+					services[service] = target
+				}
+				s.MoveTo(target.Spans().AppendEmpty())
+				return true
+			})
+			return ss.Spans().Len() == 0
+		})
+	}
+	data.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		return rs.ScopeSpans().Len() == 0
+	})
+}
+
+// service returns the first override tag present on the span and its value
+func (c *ServiceOverrideConfig) service(s ptrace.Span) (string, string) {
+	for _, tag := range c.Tags {
+		if v, ok := s.Attributes().Get(tag); ok && v.AsString() != "" {
+			return tag, v.AsString()
+		}
+	}
+	return "", ""
+}
+
+func newServiceResourceSpans(data ptrace.Traces, rs ptrace.ResourceSpans, ss ptrace.ScopeSpans, service string) ptrace.ScopeSpans {
+	res := data.ResourceSpans().AppendEmpty()
+	res.SetSchemaUrl(rs.SchemaUrl())
+	rs.Resource().CopyTo(res.Resource())
+
+	rAttr := res.Resource().Attributes()
+	rAttr.PutStr(ServiceNameAttribute, service)
+	if _, ok := rAttr.Get(lightstepConstants.ComponentNameKey); ok {
+		rAttr.PutStr(lightstepConstants.ComponentNameKey, service)
+	}
+
+	target := res.ScopeSpans().AppendEmpty()
+	target.SetSchemaUrl(ss.SchemaUrl())
+	ss.Scope().CopyTo(target.Scope())
+	return target
+}
@@ -0,0 +1,116 @@
+package lightstep_common
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	// SpanKindTag is the OpenTracing span kind tag
+	SpanKindTag = "span.kind"
+	// CustomKindAttribute keeps the original span.kind tag value
+	CustomKindAttribute = "span.custom_kind"
+)
+
+// CustomKindPolicy defines when the original span.kind tag value is kept in CustomKindAttribute
+type CustomKindPolicy string
+
+const (
+	// CustomKindUnknown emits span.custom_kind for span.kind values not recognized as Otel span kinds
+	CustomKindUnknown CustomKindPolicy = "unknown"
+	// CustomKindAlways emits span.custom_kind for every span.kind value
+	CustomKindAlways CustomKindPolicy = "always"
+	// CustomKindNever doesn't emit span.custom_kind
+	CustomKindNever CustomKindPolicy = "never"
+)
+
+var (
+	httpMethodTags = []string{"http.method", "http.request.method"}
+	peerTags       = []string{"peer.service", "peer.hostname", "peer.address", "peer.ipv4", "peer.ipv6", "peer.port"}
+	dbTags         = []string{"db.type", "db.instance", "db.statement"}
+)
+
+// SpanKindConfig represents settings of converting the span.kind tag into Otel span kind
+type SpanKindConfig struct {
+	// StripTag removes the original span.kind tag from span attributes
+	StripTag bool `mapstructure:"strip_tag"`
+	// CustomKind defines when span.custom_kind is emitted, unknown by default
+	CustomKind CustomKindPolicy `mapstructure:"custom_kind"`
+	// Infer derives the kind of spans without span.kind tag from http, peer and db tags
+	Infer bool `mapstructure:"infer"`
+}
+
+// Validate checks the span kind settings
+func (c *SpanKindConfig) Validate() error {
+	switch c.CustomKind {
+	case "", CustomKindUnknown, CustomKindAlways, CustomKindNever:
+		return nil
+	default:
+		return fmt.Errorf("unknown span_kind custom_kind policy %q", c.CustomKind)
+	}
+}
+
+// Apply sets the span kind from the span.kind tag, or infers it from other tags when enabled
+func (c *SpanKindConfig) Apply(s ptrace.Span) {
+	attr := s.Attributes()
+	value, ok := attr.Get(SpanKindTag)
+	if !ok {
+		if c.Infer {
+			s.SetKind(inferSpanKind(attr))
+		}
+		return
+	}
+
+	kind, stringKind := ParseSpanKindAttributeValue(value)
+	s.SetKind(kind)
+	if c.CustomKind == CustomKindAlways || (c.CustomKind != CustomKindNever && kind == ptrace.SpanKindUnspecified) {
+		attr.PutStr(CustomKindAttribute, stringKind)
+	}
+	if c.StripTag {
+		attr.Remove(SpanKindTag)
+	}
+}
+
+// inferSpanKind infers client spans only, an http method alone is set by client and server spans of tracers
+// not reporting peer tags alike
+func inferSpanKind(attr pcommon.Map) ptrace.SpanKind {
+	switch {
+	case hasAny(attr, dbTags):
+		return ptrace.SpanKindClient
+	case hasAny(attr, httpMethodTags) && hasAny(attr, peerTags):
+		return ptrace.SpanKindClient
+	default:
+		return ptrace.SpanKindUnspecified
+	}
+}
+
+func hasAny(attr pcommon.Map, keys []string) bool {
+	for _, k := range keys {
+		if _, ok := attr.Get(k); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSpanKindAttributeValue returns the Otel span kind and the original value of the span.kind tag
+func ParseSpanKindAttributeValue(value pcommon.Value) (ptrace.SpanKind, string) {
+	stringValue := value.AsString()
+	switch strings.ToLower(stringValue) {
+	case "internal":
+		return ptrace.SpanKindInternal, stringValue
+	case "server":
+		return ptrace.SpanKindServer, stringValue
+	case "client":
+		return ptrace.SpanKindClient, stringValue
+	case "producer":
+		return ptrace.SpanKindProducer, stringValue
+	case "consumer":
+		return ptrace.SpanKindConsumer, stringValue
+	default:
+		return ptrace.SpanKindUnspecified, stringValue
+	}
+}
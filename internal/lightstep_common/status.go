@@ -0,0 +1,142 @@
+package lightstep_common
+
+import (
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+var (
+	httpStatusCodeTags = []string{"http.status_code", "http.response.status_code"}
+	grpcStatusCodeTags = []string{"rpc.grpc.status_code", "grpc.status_code", "grpc.code"}
+
+	// grpcCodes maps gRPC status code names, without underscores, onto their numeric values
+	grpcCodes = map[string]int64{
+		"OK":                 0,
+		"CANCELLED":          1,
+		"UNKNOWN":            2,
+		"INVALIDARGUMENT":    3,
+		"DEADLINEEXCEEDED":   4,
+		"NOTFOUND":           5,
+		"ALREADYEXISTS":      6,
+		"PERMISSIONDENIED":   7,
+		"RESOURCEEXHAUSTED":  8,
+		"FAILEDPRECONDITION": 9,
+		"ABORTED":            10,
+		"OUTOFRANGE":         11,
+		"UNIMPLEMENTED":      12,
+		"INTERNAL":           13,
+		"UNAVAILABLE":        14,
+		"DATALOSS":           15,
+		"UNAUTHENTICATED":    16,
+	}
+	// grpcServerErrorCodes are gRPC status codes marking server spans as errors
+	grpcServerErrorCodes = map[int64]bool{2: true, 4: true, 12: true, 13: true, 14: true, 15: true}
+)
+
+// StatusConfig represents rules of deriving the span status from tags other than error
+type StatusConfig struct {
+	// HTTP marks server and client spans with 5xx http status code as errors
+	HTTP bool `mapstructure:"http"`
+	// HTTPClient4xx marks client spans with 4xx http status code as errors as well
+	HTTPClient4xx bool `mapstructure:"http_client_4xx"`
+	// GRPC marks client spans with a non OK gRPC status code and server spans with a server fault one as errors
+	GRPC bool `mapstructure:"grpc"`
+	// MessageTags lists tags holding the status description of error spans, the first one present wins
+	MessageTags []string `mapstructure:"message_tags"`
+}
+
+// SetDefaultStatusMessage fills the empty status message of error spans with message
+func SetDefaultStatusMessage(s ptrace.Span, message string) {
+	if s.Status().Code() != ptrace.StatusCodeError || s.Status().Message() != "" {
+		return
+	}
+	s.Status().SetMessage(message)
+}
+
+// Apply sets the error status of spans matching the rules and fills the empty description of error spans
+func (c *StatusConfig) Apply(s ptrace.Span) {
+	attr := s.Attributes()
+	if c.isHTTPError(s.Kind(), attr) || c.isGRPCError(s.Kind(), attr) {
+		s.Status().SetCode(ptrace.StatusCodeError)
+	}
+
+	if s.Status().Code() != ptrace.StatusCodeError || s.Status().Message() != "" {
+		return
+	}
+	for _, tag := range c.MessageTags {
+		if v, ok := attr.Get(tag); ok && v.AsString() != "" {
+			s.Status().SetMessage(v.AsString())
+			return
+		}
+	}
+}
+
+func (c *StatusConfig) isHTTPError(kind ptrace.SpanKind, attr pcommon.Map) bool {
+	if !c.HTTP {
+		return false
+	}
+	code, ok := intTag(attr, httpStatusCodeTags)
+	if !ok {
+		return false
+	}
+	switch kind {
+	case ptrace.SpanKindServer:
+		return code >= 500
+	case ptrace.SpanKindClient:
+		return code >= 500 || (c.HTTPClient4xx && code >= 400)
+	default:
+		return false
+	}
+}
+
+func (c *StatusConfig) isGRPCError(kind ptrace.SpanKind, attr pcommon.Map) bool {
+	if !c.GRPC {
+		return false
+	}
+	code, ok := grpcCode(attr)
+	if !ok {
+		return false
+	}
+	switch kind {
+	case ptrace.SpanKindServer:
+		return grpcServerErrorCodes[code]
+	case ptrace.SpanKindClient:
+		return code != 0
+	default:
+		return false
+	}
+}
+
+func intTag(attr pcommon.Map, keys []string) (int64, bool) {
+	for _, k := range keys {
+		v, ok := attr.Get(k)
+		if !ok {
+			continue
+		}
+		if v.Type() == pcommon.ValueTypeInt {
+			return v.Int(), true
+		}
+		if i, err := strconv.ParseInt(strings.TrimSpace(v.AsString()), 10, 64); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func grpcCode(attr pcommon.Map) (int64, bool) {
+	if code, ok := intTag(attr, grpcStatusCodeTags); ok {
+		return code, true
+	}
+	for _, k := range grpcStatusCodeTags {
+		if v, ok := attr.Get(k); ok {
+			name := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(v.AsString()), "_", ""))
+			if code, ok := grpcCodes[strings.TrimPrefix(name, "CODES.")]; ok {
+				return code, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,70 @@
+package lightstep_common
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	lightstepConstants "github.com/lightstep/lightstep-tracer-go/constants"
+)
+
+const lightstepTagPrefix = "lightstep."
+
+// LightstepTagsMode defines which lightstep.* tags are passed through into attributes
+type LightstepTagsMode string
+
+const (
+	// LightstepTagsModeOff drops lightstep.* tags
+	LightstepTagsModeOff LightstepTagsMode = "off"
+	// LightstepTagsModeAll passes every lightstep.* tag through
+	LightstepTagsModeAll LightstepTagsMode = "all"
+	// LightstepTagsModeAllowlist passes through only lightstep.* tags listed in LightstepTagsConfig.Keys
+	LightstepTagsModeAllowlist LightstepTagsMode = "allowlist"
+)
+
+// LightstepTagsConfig represents settings of passing lightstep.* tags through into attributes
+type LightstepTagsConfig struct {
+	Mode LightstepTagsMode `mapstructure:"mode"`
+	// Prefix replaces the lightstep. prefix of passed through tags, empty keeps the original keys
+	Prefix string `mapstructure:"prefix"`
+	// Keys lists the full tag keys passed through in allowlist mode
+	Keys []string `mapstructure:"keys"`
+}
+
+// Validate checks the lightstep tags settings
+func (c *LightstepTagsConfig) Validate() error {
+	switch c.Mode {
+	case "", LightstepTagsModeOff, LightstepTagsModeAll:
+		return nil
+	case LightstepTagsModeAllowlist:
+		if len(c.Keys) == 0 {
+			return fmt.Errorf("lightstep_tags mode %q requires keys", c.Mode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown lightstep_tags mode %q", c.Mode)
+	}
+}
+
+// AttributeKey returns the attribute key of a tag and whether the tag is kept. Tags prefixed with lightstep. are
+// skipped except the component name, for reporter tags the ones mapped onto Otel resource semantics and
+// the ones passed through according to the configured mode
+func (c *LightstepTagsConfig) AttributeKey(key string, resource bool) (string, bool) {
+	if !strings.HasPrefix(key, lightstepTagPrefix) || key == lightstepConstants.ComponentNameKey {
+		return key, true
+	}
+	if resource {
+		if mapped, ok := resourceTagKeys[key]; ok {
+			return mapped, true
+		}
+	}
+	switch {
+	case c.Mode == LightstepTagsModeAll, c.Mode == LightstepTagsModeAllowlist && slices.Contains(c.Keys, key):
+		if c.Prefix == "" {
+			return key, true
+		}
+		return c.Prefix + strings.TrimPrefix(key, lightstepTagPrefix), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,86 @@
+package lightstep_common
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+
+	"github.com/zalando/otelcol-lightstep-receiver/internal/telemetry"
+)
+
+// NonUTF8Mode defines how string attributes with invalid UTF8 are handled
+type NonUTF8Mode string
+
+const (
+	// NonUTF8ModeDrop drops the attribute
+	NonUTF8ModeDrop NonUTF8Mode = "drop"
+	// NonUTF8ModeReplace replaces invalid sequences with U+FFFD
+	NonUTF8ModeReplace NonUTF8Mode = "replace"
+	// NonUTF8ModeBytes stores the raw value as a bytes attribute
+	NonUTF8ModeBytes NonUTF8Mode = "bytes"
+	// NonUTF8ModeBase64 stores the base64 encoded value followed by NonUTF8Config.Base64Suffix
+	NonUTF8ModeBase64 NonUTF8Mode = "base64"
+)
+
+// NonUTF8Config represents settings of handling string attributes with invalid UTF8
+type NonUTF8Config struct {
+	Mode         NonUTF8Mode `mapstructure:"mode"`
+	Base64Suffix string      `mapstructure:"base64_suffix"`
+}
+
+// Validate checks the non UTF8 settings
+func (c *NonUTF8Config) Validate() error {
+	switch c.Mode {
+	case "", NonUTF8ModeDrop, NonUTF8ModeReplace, NonUTF8ModeBytes, NonUTF8ModeBase64:
+		return nil
+	default:
+		return fmt.Errorf("unknown non_utf8 mode %q", c.Mode)
+	}
+}
+
+// PutValue puts the attribute with invalid UTF8 key or value according to the configured mode, invalid sequences
+// of the key are replaced with U+FFFD and valid values are kept as strings unless the attribute is dropped
+func (c *NonUTF8Config) PutValue(m pcommon.Map, key string, value []byte) {
+	if c.Mode == "" || c.Mode == NonUTF8ModeDrop {
+		return
+	}
+	key = strings.ToValidUTF8(key, "�")
+	if utf8.Valid(value) {
+		m.PutStr(key, string(value))
+		return
+	}
+	switch c.Mode {
+	case NonUTF8ModeReplace:
+		m.PutStr(key, strings.ToValidUTF8(string(value), "�"))
+	case NonUTF8ModeBytes:
+		m.PutEmptyBytes(key).FromRaw(value)
+	case NonUTF8ModeBase64:
+		m.PutStr(key, base64.StdEncoding.EncodeToString(value)+c.Base64Suffix)
+	}
+}
+
+// Dropped returns the number of attributes dropped for the reported non UTF8 keys
+func (c *NonUTF8Config) Dropped(keys *[]string) uint32 {
+	if keys == nil || (c.Mode != "" && c.Mode != NonUTF8ModeDrop) {
+		return 0
+	}
+	return uint32(len(*keys))
+}
+
+// ReportNonUTF8 counts and logs attributes with non UTF8 strings
+func ReportNonUTF8(t *telemetry.Telemetry, transport string, serviceName string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	t.IncrementNonUTF8Attributes(transport, int64(len(keys)))
+	t.Logger.Info(
+		"Non UTF8 string detected",
+		zap.String("service.name", serviceName),
+		zap.String("transport", transport),
+		zap.Strings("keys", keys),
+	)
+}
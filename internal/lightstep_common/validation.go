@@ -0,0 +1,138 @@
+package lightstep_common
+
+import (
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// ValidationAction defines what is done to spans breaking a validation rule
+type ValidationAction string
+
+const (
+	// ValidationActionOff skips the rule
+	ValidationActionOff ValidationAction = "off"
+	// ValidationActionDrop drops the span
+	ValidationActionDrop ValidationAction = "drop"
+	// ValidationActionRepair fixes the span
+	ValidationActionRepair ValidationAction = "repair"
+	// ValidationActionFlag keeps the span as is listing the rule in ValidationFlagAttribute
+	ValidationActionFlag ValidationAction = "flag"
+)
+
+// ValidationRule names a check of malformed spans
+type ValidationRule string
+
+const (
+	RuleZeroTraceID      ValidationRule = "zero_trace_id"
+	RuleZeroSpanID       ValidationRule = "zero_span_id"
+	RuleSelfParent       ValidationRule = "self_parent"
+	RuleMissingTimestamp ValidationRule = "missing_timestamp"
+	RuleEndBeforeStart   ValidationRule = "end_before_start"
+	RuleEmptyName        ValidationRule = "empty_name"
+)
+
+const (
+	// ValidationFlagAttribute lists the rules broken by flagged spans
+	ValidationFlagAttribute = "lightstep.validation.failed_rules"
+	// RepairedSpanName is the name of repaired spans without operation name
+	RepairedSpanName = "unknown"
+)
+
+// ValidationConfig represents per rule actions of validating spans
+type ValidationConfig struct {
+	ZeroTraceID      ValidationAction `mapstructure:"zero_trace_id"`
+	ZeroSpanID       ValidationAction `mapstructure:"zero_span_id"`
+	SelfParent       ValidationAction `mapstructure:"self_parent"`
+	MissingTimestamp ValidationAction `mapstructure:"missing_timestamp"`
+	EndBeforeStart   ValidationAction `mapstructure:"end_before_start"`
+	EmptyName        ValidationAction `mapstructure:"empty_name"`
+}
+
+// Validate checks the validation settings
+func (c *ValidationConfig) Validate() error {
+	for rule, action := range c.actions() {
+		switch action {
+		case "", ValidationActionOff, ValidationActionDrop, ValidationActionRepair, ValidationActionFlag:
+		default:
+			return fmt.Errorf("unknown validation action %q of rule %q", action, rule)
+		}
+	}
+	// a made up id would split the trace or orphan the children of the span
+	for _, rule := range []ValidationRule{RuleZeroTraceID, RuleZeroSpanID} {
+		if c.actions()[rule] == ValidationActionRepair {
+			return fmt.Errorf("validation rule %q can not be repaired", rule)
+		}
+	}
+	return nil
+}
+
+func (c *ValidationConfig) actions() map[ValidationRule]ValidationAction {
+	return map[ValidationRule]ValidationAction{
+		RuleZeroTraceID:      c.ZeroTraceID,
+		RuleZeroSpanID:       c.ZeroSpanID,
+		RuleSelfParent:       c.SelfParent,
+		RuleMissingTimestamp: c.MissingTimestamp,
+		RuleEndBeforeStart:   c.EndBeforeStart,
+		RuleEmptyName:        c.EmptyName,
+	}
+}
+
+// Check applies the rules to the span calling report for every broken one, returns false when the span is to be dropped
+func (c *ValidationConfig) Check(s ptrace.Span, report func(rule ValidationRule)) bool {
+	keep := true
+	check := func(rule ValidationRule, action ValidationAction, broken func() bool, repair func()) {
+		if action == "" || action == ValidationActionOff || !broken() {
+			return
+		}
+		report(rule)
+		switch action {
+		case ValidationActionDrop:
+			keep = false
+		case ValidationActionRepair:
+			repair()
+		case ValidationActionFlag:
+			flags, ok := s.Attributes().Get(ValidationFlagAttribute)
+			if !ok || flags.Type() != pcommon.ValueTypeSlice {
+				flags = s.Attributes().PutEmpty(ValidationFlagAttribute)
+				flags.SetEmptySlice()
+			}
+			flags.Slice().AppendEmpty().SetStr(string(rule))
+		}
+	}
+
+	check(RuleZeroTraceID, c.ZeroTraceID, s.TraceID().IsEmpty, func() {})
+	check(RuleZeroSpanID, c.ZeroSpanID, s.SpanID().IsEmpty, func() {})
+	check(RuleSelfParent, c.SelfParent, func() bool {
+		return !s.ParentSpanID().IsEmpty() && s.ParentSpanID() == s.SpanID()
+	}, func() {
+		s.SetParentSpanID(pcommon.NewSpanIDEmpty())
+	})
+	check(RuleMissingTimestamp, c.MissingTimestamp, func() bool {
+		return s.StartTimestamp() == 0 || s.EndTimestamp() == 0
+	}, func() {
+		switch {
+		case s.StartTimestamp() == 0 && s.EndTimestamp() == 0:
+			now := pcommon.NewTimestampFromTime(time.Now())
+			s.SetStartTimestamp(now)
+			s.SetEndTimestamp(now)
+		case s.StartTimestamp() == 0:
+			s.SetStartTimestamp(s.EndTimestamp())
+		default:
+			s.SetEndTimestamp(s.StartTimestamp())
+		}
+	})
+	check(RuleEndBeforeStart, c.EndBeforeStart, func() bool {
+		return s.EndTimestamp() < s.StartTimestamp()
+	}, func() {
+		s.SetEndTimestamp(s.StartTimestamp())
+	})
+	check(RuleEmptyName, c.EmptyName, func() bool {
+		return s.Name() == ""
+	}, func() {
+		s.SetName(RepairedSpanName)
+	})
+	return keep
+}
@@ -0,0 +1,27 @@
+package lightstep_common
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestValidationConfig_Validate(t *testing.T) {
+	is := is.New(t)
+
+	c := ValidationConfig{
+		ZeroTraceID:    ValidationActionDrop,
+		ZeroSpanID:     ValidationActionFlag,
+		EndBeforeStart: ValidationActionRepair,
+	}
+	is.NoErr(c.Validate())
+
+	c = ValidationConfig{ZeroTraceID: ValidationActionRepair}
+	is.True(c.Validate() != nil)
+
+	c = ValidationConfig{ZeroSpanID: ValidationActionRepair}
+	is.True(c.Validate() != nil)
+
+	c = ValidationConfig{EmptyName: "fix"}
+	is.True(c.Validate() != nil)
+}
@@ -36,20 +36,26 @@ type ServerGRPC struct {
 	settings  *receiver.Settings
 	obsreport *receiverhelper.ObsReport
 
-	nextTraces consumer.Traces
-	telemetry  *telemetry.Telemetry
+	nextTraces      consumer.Traces
+	nextMetrics     consumer.Metrics
+	nextLogs        consumer.Logs
+	telemetry       *telemetry.Telemetry
+	transformConfig *lightstepCommon.TransformConfig
 
 	shutdownWG sync.WaitGroup
 }
 
-func NewServer(config *configgrpc.ServerConfig, set *receiver.Settings, logger *zap.Logger, nextTraces consumer.Traces, obsreport *receiverhelper.ObsReport, telemetry *telemetry.Telemetry) *ServerGRPC {
+func NewServer(config *configgrpc.ServerConfig, transformConfig *lightstepCommon.TransformConfig, set *receiver.Settings, logger *zap.Logger, nextTraces consumer.Traces, nextMetrics consumer.Metrics, nextLogs consumer.Logs, obsreport *receiverhelper.ObsReport, telemetry *telemetry.Telemetry) *ServerGRPC {
 	return &ServerGRPC{
-		config:     config,
-		settings:   set,
-		logger:     logger,
-		nextTraces: nextTraces,
-		obsreport:  obsreport,
-		telemetry:  telemetry,
+		config:          config,
+		settings:        set,
+		logger:          logger,
+		nextTraces:      nextTraces,
+		nextMetrics:     nextMetrics,
+		nextLogs:        nextLogs,
+		obsreport:       obsreport,
+		telemetry:       telemetry,
+		transformConfig: transformConfig,
 	}
 }
 
@@ -98,9 +104,9 @@ func (s *ServerGRPC) Report(ctx context.Context, rq *pb.ReportRequest) (*pb.Repo
 	ctx = client.NewContext(ctx, client.Info{})
 	receiveTimestamp := time.Now()
 	ctx = s.obsreport.StartTracesOp(ctx)
-	spanCount = len(rq.Spans)
+	spanCount = len(rq.GetSpans())
 	s.logger.Debug("report", zap.Any("incoming", rq))
-	lr := lightstep_pb.NewLightstepRequest(rq, s.telemetry, transport)
+	lr := lightstep_pb.NewLightstepRequest(rq, s.telemetry, transport, s.transformConfig)
 	if projectTraces, err = lr.ToOtel(ctx); err != nil {
 		s.telemetry.IncrementFailed(transport, 1)
 		return &pb.ReportResponse{
@@ -118,9 +124,20 @@ func (s *ServerGRPC) Report(ctx context.Context, rq *pb.ReportRequest) (*pb.Repo
 	clientInfo.Metadata = client.NewMetadata(map[string][]string{"lightstep-access-token": {projectTraces.AccessToken}})
 	ctx = client.NewContext(ctx, clientInfo)
 
-	err = s.nextTraces.ConsumeTraces(ctx, projectTraces.Traces)
+	if s.nextTraces != nil {
+		err = s.nextTraces.ConsumeTraces(ctx, projectTraces.Traces)
+	} else {
+		spanCount = 0
+	}
 	s.obsreport.EndTracesOp(ctx, "protobuf-grpc", spanCount, err)
 
+	if err == nil {
+		err = lightstepCommon.ConsumeMetrics(ctx, s.nextMetrics, s.obsreport, "protobuf-grpc", projectTraces.Metrics)
+	}
+	if err == nil {
+		err = lightstepCommon.ConsumeLogs(ctx, s.nextLogs, s.obsreport, "protobuf-grpc", projectTraces.Logs)
+	}
+
 	if err != nil {
 		return &pb.ReportResponse{
 			Errors:            []string{err.Error()},
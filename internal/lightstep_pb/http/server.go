@@ -39,25 +39,34 @@ type ServerHTTP struct {
 	settings  *receiver.Settings
 	obsreport *receiverhelper.ObsReport
 
-	nextTraces consumer.Traces
-	telemetry  *telemetry.Telemetry
+	nextTraces      consumer.Traces
+	nextMetrics     consumer.Metrics
+	nextLogs        consumer.Logs
+	telemetry       *telemetry.Telemetry
+	transformConfig *lightstepCommon.TransformConfig
 
 	shutdownWG sync.WaitGroup
 }
 
 func NewServer(
 	config *confighttp.ServerConfig,
+	transformConfig *lightstepCommon.TransformConfig,
 	set *receiver.Settings,
 	nextTraces consumer.Traces,
+	nextMetrics consumer.Metrics,
+	nextLogs consumer.Logs,
 	obsreport *receiverhelper.ObsReport,
 	telemetry *telemetry.Telemetry,
 ) *ServerHTTP {
 	return &ServerHTTP{
-		config:     config,
-		settings:   set,
-		obsreport:  obsreport,
-		nextTraces: nextTraces,
-		telemetry:  telemetry,
+		config:          config,
+		settings:        set,
+		obsreport:       obsreport,
+		nextTraces:      nextTraces,
+		nextMetrics:     nextMetrics,
+		nextLogs:        nextLogs,
+		telemetry:       telemetry,
+		transformConfig: transformConfig,
 	}
 }
 
@@ -153,9 +162,9 @@ func (s *ServerHTTP) HandleRequest(w http.ResponseWriter, rq *http.Request) {
 		return
 	}
 
-	spanCount = len(msg.Spans)
+	spanCount = len(msg.GetSpans())
 
-	lr := lightstep_pb.NewLightstepRequest(msg, s.telemetry, transport)
+	lr := lightstep_pb.NewLightstepRequest(msg, s.telemetry, transport, s.transformConfig)
 	if projectTraces, err = lr.ToOtel(ctx); err != nil {
 		s.telemetry.IncrementFailed(transport, 1)
 		s.writeResponse(w, receiveTimestamp, err)
@@ -169,7 +178,18 @@ func (s *ServerHTTP) HandleRequest(w http.ResponseWriter, rq *http.Request) {
 	clientInfo.Metadata = client.NewMetadata(map[string][]string{"lightstep-access-token": {projectTraces.AccessToken}})
 	ctx = client.NewContext(ctx, clientInfo)
 
-	err = s.nextTraces.ConsumeTraces(ctx, projectTraces.Traces)
-	s.writeResponse(w, receiveTimestamp, err)
+	if s.nextTraces != nil {
+		err = s.nextTraces.ConsumeTraces(ctx, projectTraces.Traces)
+	} else {
+		spanCount = 0
+	}
 	s.obsreport.EndTracesOp(ctx, "protobuf-http", spanCount, err)
+
+	if err == nil {
+		err = lightstepCommon.ConsumeMetrics(ctx, s.nextMetrics, s.obsreport, "protobuf-http", projectTraces.Metrics)
+	}
+	if err == nil {
+		err = lightstepCommon.ConsumeLogs(ctx, s.nextLogs, s.obsreport, "protobuf-http", projectTraces.Logs)
+	}
+	s.writeResponse(w, receiveTimestamp, err)
 }
@@ -3,12 +3,13 @@ package lightstep_pb
 import (
 	"context"
 	"encoding/binary"
-	"strings"
+	"fmt"
 	"time"
 	"unicode/utf8"
 
 	lightstepConstants "github.com/lightstep/lightstep-tracer-go/constants"
 	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
@@ -23,27 +24,21 @@ type Request struct {
 	orig      *pb.ReportRequest
 	telemetry *telemetry.Telemetry
 	transport string
+	config    *lightstepCommon.TransformConfig
 }
 
 // NewLightstepRequest creates new LightstepRequest
-func NewLightstepRequest(orig *pb.ReportRequest, t *telemetry.Telemetry, transport string) *Request {
+func NewLightstepRequest(orig *pb.ReportRequest, t *telemetry.Telemetry, transport string, config *lightstepCommon.TransformConfig) *Request {
 	return &Request{
 		orig:      orig,
 		telemetry: t,
 		transport: transport,
+		config:    config,
 	}
 }
 
 func (r *Request) reportNonUtf8(serviceName string, keys *[]string) {
-	if len(*keys) == 0 {
-		return
-	}
-	r.telemetry.IncrementNonUTF8Attributes(r.transport, int64(len(*keys)))
-	r.telemetry.Logger.Info(
-		"Non UTF8 string detected",
-		zap.String("service.name", serviceName),
-		zap.Strings("keys", *keys),
-	)
+	lightstepCommon.ReportNonUTF8(r.telemetry, r.transport, serviceName, *keys)
 }
 
 // ToOtel transforms data from lightstep.ReportRequest into Otel ptrace.Traces
@@ -52,21 +47,30 @@ func (r *Request) ToOtel(ctx context.Context) (*lightstepCommon.ProjectTraces, e
 	defer span.End()
 
 	result := &lightstepCommon.ProjectTraces{}
+	if r.config == nil {
+		r.config = &lightstepCommon.TransformConfig{}
+	}
+	if r.orig == nil {
+		span.SetStatus(codes.Error, lightstepCommon.ErrNoReportRequest.Error())
+		return nil, lightstepCommon.ErrNoReportRequest
+	}
 
-	if r.orig.Auth == nil || r.orig.Auth.AccessToken == "" {
+	if r.orig.GetAuth().GetAccessToken() == "" {
 		span.SetStatus(codes.Error, lightstepCommon.ErrNoAccessToken.Error())
 	} else {
-		result.AccessToken = r.orig.Auth.AccessToken
+		result.AccessToken = r.orig.GetAuth().GetAccessToken()
 	}
 
 	data := ptrace.NewTraces()
 	rs := data.ResourceSpans().AppendEmpty()
+	rs.SetSchemaUrl(r.config.SemConv.SchemaURL())
 	rAttr := rs.Resource().Attributes()
 
-	nonUtf8Keys, err := r.kvToAttr(r.orig.Reporter.Tags, &rAttr)
+	nonUtf8Keys, err := r.kvToAttr(r.orig.GetReporter().GetTags(), &rAttr, true)
 	if err != nil {
 		span.SetStatus(codes.Error, "non-utf8-keys")
 		r.reportNonUtf8(result.ServiceName, nonUtf8Keys)
+		rs.Resource().SetDroppedAttributesCount(r.config.NonUTF8.Dropped(nonUtf8Keys))
 	}
 
 	serviceName, ok := rAttr.Get(lightstepConstants.ComponentNameKey)
@@ -76,37 +80,48 @@ func (r *Request) ToOtel(ctx context.Context) (*lightstepCommon.ProjectTraces, e
 	} else {
 		span.SetStatus(codes.Error, lightstepCommon.ErrNoServiceName.Error())
 	}
+	if reporterID := r.orig.GetReporter().GetReporterId(); reporterID != 0 {
+		lightstepCommon.PutRuntimeIdentity(rAttr, fmt.Sprintf("%016x", reporterID), 0)
+	}
 
-	if r.orig.InternalMetrics != nil {
-		for _, m := range r.orig.InternalMetrics.Counts {
-			if m.Name == "spans.dropped" {
-				result.ClientSpansDropped = m.GetIntValue()
-				break
-			}
+	for _, m := range r.orig.GetInternalMetrics().GetCounts() {
+		if m.GetName() == "spans.dropped" {
+			result.ClientSpansDropped = m.GetIntValue()
+			break
 		}
 	}
+	result.Metrics = r.convertInternalMetrics(rAttr)
+	logs := r.convertInternalLogs(rAttr, result.ServiceName)
+
+	clockOffset := r.config.ClockCorrection.Offset(r.orig.GetTimestampOffsetMicros())
+	logs.ApplyClockCorrection(clockOffset)
 
 	ss := rs.ScopeSpans().AppendEmpty()
-	for _, span := range r.orig.Spans {
-		s := ss.Spans().AppendEmpty()
-		s.SetSpanID(convertSpanID(span.GetSpanContext().SpanId))
-		s.SetTraceID(convertTraceID(span.GetSpanContext().TraceId))
+	for _, span := range r.orig.GetSpans() {
+		if span == nil {
+			continue
+		}
+		s := ptrace.NewSpan()
+		s.SetSpanID(convertSpanID(span.GetSpanContext().GetSpanId()))
+		s.SetTraceID(lightstepCommon.NewTraceID(r.traceIDUpper(span.GetTags()), span.GetSpanContext().GetTraceId()))
 		s.SetName(span.GetOperationName())
 
-		if len(span.References) == 1 {
-			s.SetParentSpanID(convertSpanID(span.References[0].SpanContext.SpanId))
-		}
+		r.convertReferences(span, s)
 
-		startTimestamp := span.StartTimestamp.AsTime()
+		startTimestamp := span.GetStartTimestamp().AsTime()
 		s.SetStartTimestamp(pcommon.NewTimestampFromTime(startTimestamp))
 
-		endTimeStamp := startTimestamp.Add(time.Duration(span.DurationMicros) * time.Microsecond)
+		endTimeStamp := startTimestamp.Add(time.Duration(span.GetDurationMicros()) * time.Microsecond)
 		s.SetEndTimestamp(pcommon.NewTimestampFromTime(endTimeStamp))
 
 		attr := s.Attributes()
-		if nonUtf8Keys, err = r.kvToAttr(span.Tags, &attr); err != nil {
+		if nonUtf8Keys, err = r.kvToAttr(span.GetTags(), &attr, false); err != nil {
 			r.reportNonUtf8(result.ServiceName, nonUtf8Keys)
+			s.SetDroppedAttributesCount(r.config.NonUTF8.Dropped(nonUtf8Keys))
 		}
+		r.config.RemoveTraceIDUpperTag(attr)
+		r.config.TypeCoercion.Coerce(attr, false)
+		r.config.Baggage.CopyBaggage(span.GetSpanContext().GetBaggage(), attr)
 
 		if value, ok := attr.Get("error"); ok {
 			if lightstepCommon.IsErrorAttributeValueActuallyError(value) {
@@ -115,51 +130,202 @@ func (r *Request) ToOtel(ctx context.Context) (*lightstepCommon.ProjectTraces, e
 			}
 		}
 
-		if spanKind, ok := attr.Get("span.kind"); ok {
-			otelSpanKind, stringSpanKind := lightstepCommon.ParseSpanKindAttributeValue(spanKind)
-			attr.PutStr("span.custom_kind", stringSpanKind)
-			s.SetKind(otelSpanKind)
-		}
+		r.config.SpanKind.Apply(s)
+		r.config.Status.Apply(s)
 
-		for _, log := range span.Logs {
+		for _, log := range span.GetLogs() {
+			if log == nil {
+				continue
+			}
 			ev := s.Events().AppendEmpty()
-			ev.SetTimestamp(pcommon.NewTimestampFromTime(log.Timestamp.AsTime()))
+			ev.SetTimestamp(pcommon.NewTimestampFromTime(log.GetTimestamp().AsTime()))
 
 			evAttr := ev.Attributes()
-			if nonUtf8Keys, err = r.kvToAttr(log.Fields, &evAttr); err != nil {
+			if nonUtf8Keys, err = r.kvToAttr(log.GetFields(), &evAttr, false); err != nil {
 				r.reportNonUtf8(result.ServiceName, nonUtf8Keys)
+				ev.SetDroppedAttributesCount(r.config.NonUTF8.Dropped(nonUtf8Keys))
 			}
+			r.config.TypeCoercion.Coerce(evAttr, false)
 			if evName, ok := evAttr.Get("event"); ok {
 				ev.SetName(evName.Str())
 				evAttr.Remove("event")
 			}
+			lightstepCommon.ConvertErrorEvent(ev)
+		}
+		lightstepCommon.SetExceptionStatusMessage(s)
+
+		lightstepCommon.ApplyClockCorrection(s, clockOffset)
+		if !r.config.Validation.Check(s, func(rule lightstepCommon.ValidationRule) {
+			r.telemetry.IncrementInvalidSpans(string(rule), result.ServiceName, 1)
+		}) {
+			continue
+		}
+		r.config.SemConv.TranslateSpan(s)
+
+		if r.config.Logs.SpanEvents {
+			logs.AppendSpanEvents(s)
 		}
+		s.MoveTo(ss.Spans().AppendEmpty())
 	}
+	r.config.ServiceOverride.Apply(data)
 	result.Traces = data
+	result.Logs = logs.Logs()
 	return result, nil
 }
 
-func (r *Request) kvToAttr(kv []*pb.KeyValue, p *pcommon.Map) (*[]string, error) {
+// convertInternalMetrics converts tracer internal metrics into Otel metrics
+func (r *Request) convertInternalMetrics(resource pcommon.Map) pmetric.Metrics {
+	im := r.orig.GetInternalMetrics()
+	if im == nil {
+		return pmetric.NewMetrics()
+	}
+
+	var start, end pcommon.Timestamp
+	if im.GetStartTimestamp() != nil {
+		startTime := im.GetStartTimestamp().AsTime()
+		start = pcommon.NewTimestampFromTime(startTime)
+		end = pcommon.NewTimestampFromTime(startTime.Add(time.Duration(im.GetDurationMicros()) * time.Microsecond))
+	} else {
+		end = pcommon.NewTimestampFromTime(time.Now())
+	}
+	tm := lightstepCommon.NewTracerMetrics(resource, start, end)
+
+	for _, m := range im.GetCounts() {
+		switch v := m.GetValue().(type) {
+		case *pb.MetricsSample_IntValue:
+			tm.AddCountInt(m.GetName(), v.IntValue)
+		case *pb.MetricsSample_DoubleValue:
+			tm.AddCountDouble(m.GetName(), v.DoubleValue)
+		}
+	}
+	for _, m := range im.GetGauges() {
+		switch v := m.GetValue().(type) {
+		case *pb.MetricsSample_IntValue:
+			tm.AddGaugeInt(m.GetName(), v.IntValue)
+		case *pb.MetricsSample_DoubleValue:
+			tm.AddGaugeDouble(m.GetName(), v.DoubleValue)
+		}
+	}
+	return tm.Metrics()
+}
+
+// convertInternalLogs converts tracer internal logs into Otel log records
+func (r *Request) convertInternalLogs(resource pcommon.Map, serviceName string) *lightstepCommon.TracerLogs {
+	logs := lightstepCommon.NewTracerLogs(resource)
+	for _, log := range r.orig.GetInternalMetrics().GetLogs() {
+		if log == nil {
+			continue
+		}
+		lr := logs.AppendEmpty()
+		lr.SetTimestamp(pcommon.NewTimestampFromTime(log.GetTimestamp().AsTime()))
+
+		attr := lr.Attributes()
+		if nonUtf8Keys, err := r.kvToAttr(log.GetFields(), &attr, false); err != nil {
+			r.reportNonUtf8(serviceName, nonUtf8Keys)
+			lr.SetDroppedAttributesCount(r.config.NonUTF8.Dropped(nonUtf8Keys))
+		}
+		r.config.TypeCoercion.Coerce(attr, false)
+		if message, ok := attr.Get("message"); ok {
+			lr.Body().SetStr(message.AsString())
+			attr.Remove("message")
+		}
+		if evName, ok := attr.Get("event"); ok {
+			lr.SetEventName(evName.AsString())
+			attr.Remove("event")
+		}
+		attr.PutBool(lightstepCommon.InternalLogAttribute, true)
+	}
+	return logs
+}
+
+// convertReferences sets the first CHILD_OF reference as the parent span,
+// every other reference becomes a span link keeping its OpenTracing relationship
+func (r *Request) convertReferences(span *pb.Span, s ptrace.Span) {
+	parentSet := false
+	for _, ref := range span.GetReferences() {
+		refCtx := ref.GetSpanContext()
+		if refCtx == nil {
+			continue
+		}
+
+		if !parentSet && ref.GetRelationship() == pb.Reference_CHILD_OF {
+			s.SetParentSpanID(convertSpanID(refCtx.GetSpanId()))
+			parentSet = true
+			continue
+		}
+
+		link := s.Links().AppendEmpty()
+		link.SetSpanID(convertSpanID(refCtx.GetSpanId()))
+		if refCtx.GetTraceId() != 0 && refCtx.GetTraceId() != span.GetSpanContext().GetTraceId() {
+			link.SetTraceID(convertTraceID(refCtx.GetTraceId()))
+		} else {
+			link.SetTraceID(s.TraceID())
+		}
+		link.Attributes().PutStr(lightstepCommon.RefTypeAttribute, refTypeValue(ref.GetRelationship()))
+	}
+}
+
+// traceIDUpper looks up the upper half of the trace id in the configured span tag
+func (r *Request) traceIDUpper(tags []*pb.KeyValue) uint64 {
+	if r.config.TraceID.UpperTag == "" {
+		return 0
+	}
+	for _, t := range tags {
+		if t.GetKey() != r.config.TraceID.UpperTag {
+			continue
+		}
+		switch v := t.GetValue().(type) {
+		case *pb.KeyValue_IntValue:
+			return uint64(v.IntValue)
+		case *pb.KeyValue_StringValue:
+			if upper, ok := lightstepCommon.ParseTraceIDUpper(string(v.StringValue)); ok {
+				return upper
+			}
+		}
+		r.telemetry.Logger.Debug("can't parse upper trace id", zap.String("key", t.GetKey()))
+	}
+	return 0
+}
+
+func refTypeValue(rel pb.Reference_Relationship) string {
+	switch rel {
+	case pb.Reference_FOLLOWS_FROM:
+		return lightstepCommon.RefTypeFollowsFrom
+	default:
+		return lightstepCommon.RefTypeChildOf
+	}
+}
+
+// kvToAttr puts key values into attributes, resource is set for Reporter tags to map well-known lightstep tags
+func (r *Request) kvToAttr(kv []*pb.KeyValue, p *pcommon.Map, resource bool) (*[]string, error) {
 	res := *p
 	var nonUtf8Keys []string
 	for _, t := range kv {
-		if strings.HasPrefix(t.Key, "lightstep.") && t.Key != lightstepConstants.ComponentNameKey {
+		if t == nil {
+			continue
+		}
+		key, ok := r.config.LightstepTags.AttributeKey(t.GetKey(), resource)
+		if !ok {
+			continue
+		}
+		if _, exists := res.Get(key); exists && key != t.GetKey() {
 			continue
 		}
 		if v, ok := t.GetValue().(*pb.KeyValue_StringValue); ok {
 			if !utf8.Valid(v.StringValue) {
-				nonUtf8Keys = append(nonUtf8Keys, t.Key)
+				nonUtf8Keys = append(nonUtf8Keys, key)
+				r.config.NonUTF8.PutValue(res, key, v.StringValue)
 				continue
 			}
-			res.PutStr(t.Key, string(v.StringValue))
+			res.PutStr(key, string(v.StringValue))
 		} else if v, ok := t.GetValue().(*pb.KeyValue_BoolValue); ok {
-			res.PutBool(t.Key, v.BoolValue)
+			res.PutBool(key, v.BoolValue)
 		} else if v, ok := t.GetValue().(*pb.KeyValue_DoubleValue); ok {
-			res.PutDouble(t.Key, v.DoubleValue)
+			res.PutDouble(key, v.DoubleValue)
 		} else if v, ok := t.GetValue().(*pb.KeyValue_IntValue); ok {
-			res.PutInt(t.Key, v.IntValue)
+			res.PutInt(key, v.IntValue)
 		} else if v, ok := t.GetValue().(*pb.KeyValue_JsonValue); ok {
-			res.PutStr(t.Key, v.JsonValue)
+			r.config.JSONValue.PutJSONValue(res, key, v.JsonValue)
 		}
 	}
 	if len(nonUtf8Keys) > 0 {
@@ -175,10 +341,5 @@ func convertSpanID(v uint64) pcommon.SpanID {
 }
 
 func convertTraceID(v uint64) pcommon.TraceID {
-	b1 := make([]byte, 8)
-	b2 := make([]byte, 8)
-	binary.BigEndian.PutUint64(b2, v)
-
-	b1 = append(b1, b2...)
-	return pcommon.TraceID(b1)
+	return lightstepCommon.NewTraceID(0, v)
 }
@@ -2,17 +2,21 @@ package lightstep_pb
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/matryer/is"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/receiver"
 	"go.opentelemetry.io/otel/trace/noop"
 	"go.uber.org/zap"
 
+	lightstepCommon "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_common"
 	pb "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_pb/collectorpb"
 	"github.com/zalando/otelcol-lightstep-receiver/internal/telemetry"
 )
@@ -242,12 +246,537 @@ func TestTransformation_GetClientDropSpans(t *testing.T) {
 	is.Equal(rqOtel.ClientSpansDropped, int64(10))
 }
 
+func TestTransformation_InternalMetrics(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{
+				Tags: []*pb.KeyValue{
+					{
+						Key:   "lightstep.component_name",
+						Value: &pb.KeyValue_StringValue{StringValue: []byte("service.name")},
+					},
+				},
+			},
+			InternalMetrics: &pb.InternalMetrics{
+				StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+				DurationMicros: 1000000,
+				Counts: []*pb.MetricsSample{
+					{Name: "spans.dropped", Value: &pb.MetricsSample_IntValue{IntValue: 10}},
+					{Name: "logs.dropped", Value: &pb.MetricsSample_DoubleValue{DoubleValue: 2}},
+				},
+				Gauges: []*pb.MetricsSample{
+					{Name: "buffer.size", Value: &pb.MetricsSample_IntValue{IntValue: 512}},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(rqOtel.Metrics.DataPointCount(), 3)
+
+	rm := rqOtel.Metrics.ResourceMetrics().At(0)
+	v, ok := rm.Resource().Attributes().Get("service.name")
+	is.True(ok)
+	is.Equal(v.Str(), "service.name")
+
+	metrics := rm.ScopeMetrics().At(0).Metrics()
+	is.Equal(metrics.At(0).Name(), "lightstep.tracer.spans.dropped")
+	is.Equal(metrics.At(0).Sum().AggregationTemporality(), pmetric.AggregationTemporalityDelta)
+	is.Equal(metrics.At(0).Sum().DataPoints().At(0).IntValue(), int64(10))
+	is.Equal(metrics.At(0).Sum().DataPoints().At(0).StartTimestamp().AsTime().Unix(), int64(1718207928))
+	is.Equal(metrics.At(0).Sum().DataPoints().At(0).Timestamp().AsTime().Unix(), int64(1718207929))
+	is.Equal(metrics.At(1).Sum().DataPoints().At(0).DoubleValue(), 2.0)
+	is.Equal(metrics.At(2).Name(), "lightstep.tracer.buffer.size")
+	is.Equal(metrics.At(2).Gauge().DataPoints().At(0).IntValue(), int64(512))
+	is.Equal(metrics.At(2).Gauge().DataPoints().At(0).StartTimestamp(), pcommon.Timestamp(0))
+	is.Equal(metrics.At(2).Gauge().DataPoints().At(0).Timestamp().AsTime().Unix(), int64(1718207929))
+}
+
+func TestTransformation_References(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			Spans: []*pb.Span{
+				{
+					SpanContext: &pb.SpanContext{
+						TraceId: 5633477863404139573,
+						SpanId:  13131584195926266923,
+					},
+					OperationName: "operation-name",
+					References: []*pb.Reference{
+						{
+							Relationship: pb.Reference_FOLLOWS_FROM,
+							SpanContext: &pb.SpanContext{
+								TraceId: 11823890906499043596,
+								SpanId:  1,
+							},
+						},
+						{
+							Relationship: pb.Reference_CHILD_OF,
+							SpanContext: &pb.SpanContext{
+								TraceId: 5633477863404139573,
+								SpanId:  2647710007585667870,
+							},
+						},
+						{
+							Relationship: pb.Reference_CHILD_OF,
+							SpanContext: &pb.SpanContext{
+								SpanId: 3,
+							},
+						},
+					},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+
+	span := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(span.ParentSpanID().String(), "24be8e394663fb1e")
+	is.Equal(span.Links().Len(), 2)
+
+	link := span.Links().At(0)
+	is.Equal(link.TraceID().String(), "0000000000000000a416e62240d8d10c")
+	is.Equal(link.SpanID().String(), "0000000000000001")
+	v, ok := link.Attributes().Get("opentracing.ref_type")
+	is.True(ok)
+	is.Equal(v.Str(), "follows_from")
+
+	link = span.Links().At(1)
+	is.Equal(link.TraceID(), span.TraceID())
+	is.Equal(link.SpanID().String(), "0000000000000003")
+	v, ok = link.Attributes().Get("opentracing.ref_type")
+	is.True(ok)
+	is.Equal(v.Str(), "child_of")
+}
+
+func TestTransformation_Baggage(t *testing.T) {
+	is := is.New(t)
+	newRequest := func(config *lightstepCommon.TransformConfig) *Request {
+		return &Request{
+			orig: &pb.ReportRequest{
+				Reporter: &pb.Reporter{},
+				Spans: []*pb.Span{
+					{
+						SpanContext: &pb.SpanContext{
+							TraceId: 5633477863404139573,
+							SpanId:  13131584195926266923,
+							Baggage: map[string]string{
+								"tenant":     "tenant-1",
+								"experiment": "exp-1",
+							},
+						},
+						StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					},
+				},
+			},
+			telemetry: initTelemetry(),
+			config:    config,
+		}
+	}
+
+	rqOtel, err := newRequest(nil).ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes().Len(), 0)
+
+	rqOtel, err = newRequest(&lightstepCommon.TransformConfig{
+		Baggage: lightstepCommon.BaggageConfig{Mode: lightstepCommon.BaggageModeAll, Prefix: "baggage."},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	attr := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	is.Equal(attr.Len(), 2)
+	v, ok := attr.Get("baggage.tenant")
+	is.True(ok)
+	is.Equal(v.Str(), "tenant-1")
+	v, ok = attr.Get("baggage.experiment")
+	is.True(ok)
+	is.Equal(v.Str(), "exp-1")
+
+	rqOtel, err = newRequest(&lightstepCommon.TransformConfig{
+		Baggage: lightstepCommon.BaggageConfig{Mode: lightstepCommon.BaggageModeAllowlist, Keys: []string{"tenant"}},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	attr = rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	is.Equal(attr.Len(), 1)
+	v, ok = attr.Get("tenant")
+	is.True(ok)
+	is.Equal(v.Str(), "tenant-1")
+}
+
+func TestTransformation_ClockCorrection(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			Spans: []*pb.Span{
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					DurationMicros: 100,
+					Logs: []*pb.Log{
+						{Timestamp: &timestamp.Timestamp{Seconds: 1718207928}},
+						{},
+					},
+				},
+			},
+			InternalMetrics: &pb.InternalMetrics{
+				Logs: []*pb.Log{{Timestamp: &timestamp.Timestamp{Seconds: 1718207928}}},
+			},
+			TimestampOffsetMicros: -1500,
+		},
+		telemetry: initTelemetry(),
+		config: &lightstepCommon.TransformConfig{
+			ClockCorrection: lightstepCommon.ClockCorrectionConfig{Enabled: true},
+		},
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+
+	span := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(span.StartTimestamp().AsTime().UnixMicro(), int64(1718207927998500))
+	is.Equal(span.EndTimestamp().AsTime().UnixMicro(), int64(1718207927998600))
+	is.Equal(span.Events().At(0).Timestamp().AsTime().UnixMicro(), int64(1718207927998500))
+	is.Equal(span.Events().At(1).Timestamp(), pcommon.Timestamp(0))
+	v, ok := span.Attributes().Get(lightstepCommon.ClockCorrectionAttribute)
+	is.True(ok)
+	is.Equal(v.Int(), int64(-1500))
+
+	lr := rqOtel.Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	is.Equal(lr.Timestamp().AsTime().UnixMicro(), int64(1718207927998500))
+	v, ok = lr.Attributes().Get(lightstepCommon.ClockCorrectionAttribute)
+	is.True(ok)
+	is.Equal(v.Int(), int64(-1500))
+}
+
+func TestTransformation_JSONValue(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			Spans: []*pb.Span{
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					Tags: []*pb.KeyValue{
+						{Key: "array", Value: &pb.KeyValue_JsonValue{JsonValue: `["a", 1, 1.5, true, null]`}},
+						{Key: "object", Value: &pb.KeyValue_JsonValue{JsonValue: `{"k": {"nested": ["v"]}}`}},
+						{Key: "too-deep", Value: &pb.KeyValue_JsonValue{JsonValue: `[[[["v"]]]]`}},
+						{Key: "too-long", Value: &pb.KeyValue_JsonValue{JsonValue: `["` + strings.Repeat("a", 64) + `"]`}},
+						{Key: "invalid", Value: &pb.KeyValue_JsonValue{JsonValue: `{"k": `}},
+						{Key: "scalar", Value: &pb.KeyValue_JsonValue{JsonValue: `42`}},
+					},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+		config: &lightstepCommon.TransformConfig{
+			JSONValue: lightstepCommon.JSONValueConfig{Parse: true, MaxDepth: 3, MaxSize: 64},
+		},
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+	attr := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+
+	v, ok := attr.Get("array")
+	is.True(ok)
+	is.Equal(v.Type(), pcommon.ValueTypeSlice)
+	is.Equal(v.Slice().Len(), 5)
+	is.Equal(v.Slice().At(0).Str(), "a")
+	is.Equal(v.Slice().At(1).Int(), int64(1))
+	is.Equal(v.Slice().At(2).Double(), 1.5)
+	is.Equal(v.Slice().At(3).Bool(), true)
+	is.Equal(v.Slice().At(4).Type(), pcommon.ValueTypeEmpty)
+
+	v, ok = attr.Get("object")
+	is.True(ok)
+	is.Equal(v.Type(), pcommon.ValueTypeMap)
+	nested, ok := v.Map().Get("k")
+	is.True(ok)
+	inner, ok := nested.Map().Get("nested")
+	is.True(ok)
+	is.Equal(inner.Slice().At(0).Str(), "v")
+
+	for key, raw := range map[string]string{
+		"too-deep": `[[[["v"]]]]`,
+		"too-long": `["` + strings.Repeat("a", 64) + `"]`,
+		"invalid":  `{"k": `,
+		"scalar":   `42`,
+	} {
+		v, ok = attr.Get(key)
+		is.True(ok)
+		is.Equal(v.Str(), raw)
+	}
+}
+
+func TestTransformation_SemConv(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			Spans: []*pb.Span{
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					Tags: []*pb.KeyValue{
+						{Key: "http.status_code", Value: &pb.KeyValue_IntValue{IntValue: 200}},
+						{Key: "message_bus.destination", Value: &pb.KeyValue_StringValue{StringValue: []byte("queue")}},
+						{Key: "db.type", Value: &pb.KeyValue_StringValue{StringValue: []byte("sql")}},
+						{Key: "peer.hostname", Value: &pb.KeyValue_StringValue{StringValue: []byte("db-1")}},
+					},
+					Logs: []*pb.Log{
+						{
+							Timestamp: &timestamp.Timestamp{Seconds: 1718207928},
+							Fields: []*pb.KeyValue{
+								{Key: "db.instance", Value: &pb.KeyValue_StringValue{StringValue: []byte("orders")}},
+							},
+						},
+					},
+				},
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 2},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					Tags: []*pb.KeyValue{
+						{Key: "span.kind", Value: &pb.KeyValue_StringValue{StringValue: []byte("server")}},
+						{Key: "peer.hostname", Value: &pb.KeyValue_StringValue{StringValue: []byte("client-1")}},
+						{Key: "peer.port", Value: &pb.KeyValue_IntValue{IntValue: 50123}},
+						{Key: "db.type", Value: &pb.KeyValue_StringValue{StringValue: []byte("redis")}},
+					},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+		config: &lightstepCommon.TransformConfig{
+			SemConv: lightstepCommon.SemConvConfig{Version: "1.21.0"},
+		},
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(rqOtel.ResourceSpans().At(0).SchemaUrl(), "https://opentelemetry.io/schemas/1.21.0")
+
+	spans := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	attr := spans.At(0).Attributes()
+	is.Equal(attr.Len(), 4)
+	for key, expected := range map[string]any{
+		"http.response.status_code":  int64(200),
+		"messaging.destination.name": "queue",
+		"db.system":                  "other_sql",
+		"server.address":             "db-1",
+	} {
+		v, ok := attr.Get(key)
+		is.True(ok)
+		is.Equal(v.AsRaw(), expected)
+	}
+	v, ok := spans.At(0).Events().At(0).Attributes().Get("db.name")
+	is.True(ok)
+	is.Equal(v.Str(), "orders")
+
+	attr = spans.At(1).Attributes()
+	for key, expected := range map[string]any{
+		"client.address": "client-1",
+		"client.port":    int64(50123),
+		"db.system":      "redis",
+	} {
+		v, ok := attr.Get(key)
+		is.True(ok)
+		is.Equal(v.AsRaw(), expected)
+	}
+	_, ok = attr.Get("server.address")
+	is.True(!ok)
+}
+
+func TestTransformation_InternalLogs(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			InternalMetrics: &pb.InternalMetrics{
+				Logs: []*pb.Log{
+					{
+						Timestamp: &timestamp.Timestamp{Seconds: 1718207928},
+						Fields: []*pb.KeyValue{
+							{Key: "message", Value: &pb.KeyValue_StringValue{StringValue: []byte("flush failed")}},
+							{Key: "event", Value: &pb.KeyValue_StringValue{StringValue: []byte("flush")}},
+							{Key: "attempt", Value: &pb.KeyValue_IntValue{IntValue: 3}},
+						},
+					},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(rqOtel.Logs.LogRecordCount(), 1)
+
+	lr := rqOtel.Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	is.Equal(lr.Body().Str(), "flush failed")
+	is.Equal(lr.EventName(), "flush")
+	is.Equal(lr.Timestamp().AsTime().Unix(), int64(1718207928))
+	v, ok := lr.Attributes().Get("attempt")
+	is.True(ok)
+	is.Equal(v.Int(), int64(3))
+	v, ok = lr.Attributes().Get(lightstepCommon.InternalLogAttribute)
+	is.True(ok)
+	is.Equal(v.Bool(), true)
+}
+
+func TestTransformation_ErrorTag(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			Spans: []*pb.Span{
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					Tags: []*pb.KeyValue{
+						{Key: "error", Value: &pb.KeyValue_StringValue{StringValue: []byte("true")}},
+					},
+				},
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 2},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					Tags: []*pb.KeyValue{
+						{Key: "error", Value: &pb.KeyValue_BoolValue{BoolValue: false}},
+					},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+
+	spans := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	is.Equal(spans.At(0).Status().Code(), ptrace.StatusCodeError)
+	is.Equal(spans.At(0).Status().Message(), "")
+	_, ok := spans.At(0).Attributes().Get("error")
+	is.True(!ok)
+
+	is.Equal(spans.At(1).Status().Code(), ptrace.StatusCodeUnset)
+	v, ok := spans.At(1).Attributes().Get("error")
+	is.True(ok)
+	is.Equal(v.Bool(), false)
+}
+
+func TestTransformation_TypeSchema(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			Spans: []*pb.Span{
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					Tags: []*pb.KeyValue{
+						{Key: "http.status_code", Value: &pb.KeyValue_StringValue{StringValue: []byte("500")}},
+						{Key: "customer.id", Value: &pb.KeyValue_IntValue{IntValue: 42}},
+						{Key: "ratio", Value: &pb.KeyValue_StringValue{StringValue: []byte("0.25")}},
+					},
+					Logs: []*pb.Log{
+						{
+							Timestamp: &timestamp.Timestamp{Seconds: 1718207928},
+							Fields: []*pb.KeyValue{
+								{Key: "http.status_code", Value: &pb.KeyValue_StringValue{StringValue: []byte("500")}},
+								{Key: "customer.id", Value: &pb.KeyValue_IntValue{IntValue: 42}},
+							},
+						},
+					},
+				},
+			},
+			InternalMetrics: &pb.InternalMetrics{
+				Logs: []*pb.Log{
+					{
+						Timestamp: &timestamp.Timestamp{Seconds: 1718207928},
+						Fields: []*pb.KeyValue{
+							{Key: "http.status_code", Value: &pb.KeyValue_StringValue{StringValue: []byte("500")}},
+							{Key: "customer.id", Value: &pb.KeyValue_IntValue{IntValue: 42}},
+						},
+					},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+		config: &lightstepCommon.TransformConfig{
+			TypeCoercion: lightstepCommon.TypeCoercionConfig{
+				Infer: true,
+				Schema: map[string]lightstepCommon.AttributeType{
+					"http.status_code": lightstepCommon.AttributeTypeInt,
+					"customer.id":      lightstepCommon.AttributeTypeString,
+				},
+			},
+		},
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+
+	attr := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	for key, expected := range map[string]any{
+		"http.status_code": int64(500),
+		"customer.id":      "42",
+		"ratio":            "0.25",
+	} {
+		v, ok := attr.Get(key)
+		is.True(ok)
+		is.Equal(v.AsRaw(), expected)
+	}
+
+	evAttr := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Events().At(0).Attributes()
+	lrAttr := rqOtel.Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+	for _, attr := range []pcommon.Map{evAttr, lrAttr} {
+		v, ok := attr.Get("http.status_code")
+		is.True(ok)
+		is.Equal(v.AsRaw(), int64(500))
+		v, ok = attr.Get("customer.id")
+		is.True(ok)
+		is.Equal(v.AsRaw(), "42")
+	}
+}
+
 func TestConvertTraceID(t *testing.T) {
 	is := is.New(t)
 	c := convertTraceID(11823890906499043596)
 	is.Equal(c.String(), "0000000000000000a416e62240d8d10c")
 }
 
+func TestTransformation_TraceIDUpperTag(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			Spans: []*pb.Span{
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 11823890906499043596, SpanId: 1},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					Tags: []*pb.KeyValue{
+						{
+							Key:   "trace_id_upper",
+							Value: &pb.KeyValue_StringValue{StringValue: []byte("4bf92f3577b34da6")},
+						},
+					},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+		config: &lightstepCommon.TransformConfig{
+			TraceID: lightstepCommon.TraceIDConfig{UpperTag: "trace_id_upper"},
+		},
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+
+	span := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(span.TraceID().String(), "4bf92f3577b34da6a416e62240d8d10c")
+	_, ok := span.Attributes().Get("trace_id_upper")
+	is.True(!ok)
+}
+
 func TestConvertSpanID(t *testing.T) {
 	is := is.New(t)
 	c := convertSpanID(11823890906499043596)
@@ -284,3 +813,693 @@ func TestInvalidUTF8(t *testing.T) {
 	is.Equal(rq.telemetry.NonUTF8Attributes[rq.transport], int64(1))
 	is.Equal(res.ResourceSpans().At(0).Resource().Attributes().Len(), 2)
 }
+
+func TestInvalidUTF8_Modes(t *testing.T) {
+	for _, tc := range []struct {
+		mode     lightstepCommon.NonUTF8Mode
+		expected any
+		dropped  uint32
+	}{
+		{mode: lightstepCommon.NonUTF8ModeDrop, expected: nil, dropped: 1},
+		{mode: lightstepCommon.NonUTF8ModeReplace, expected: "pok�mon"},
+		{mode: lightstepCommon.NonUTF8ModeBytes, expected: []byte("pok\xE9mon")},
+		{mode: lightstepCommon.NonUTF8ModeBase64, expected: "cG9r6W1vbg==;base64"},
+	} {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			is := is.New(t)
+			rq := Request{
+				orig: &pb.ReportRequest{
+					Reporter: &pb.Reporter{},
+					Spans: []*pb.Span{
+						{
+							SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+							StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+							Tags: []*pb.KeyValue{
+								{Key: "latin1", Value: &pb.KeyValue_StringValue{StringValue: []byte("pok\xE9mon")}},
+							},
+						},
+					},
+				},
+				transport: "foo",
+				telemetry: initTelemetry(),
+				config: &lightstepCommon.TransformConfig{
+					NonUTF8: lightstepCommon.NonUTF8Config{Mode: tc.mode, Base64Suffix: ";base64"},
+				},
+			}
+			rqOtel, err := rq.ToOtel(context.Background())
+			is.NoErr(err)
+			is.Equal(rq.telemetry.NonUTF8Attributes[rq.transport], int64(1))
+
+			s := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+			is.Equal(s.DroppedAttributesCount(), tc.dropped)
+			v, ok := s.Attributes().Get("latin1")
+			is.Equal(ok, tc.expected != nil)
+			if ok {
+				is.Equal(v.AsRaw(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestTransformation_ReporterTags(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{
+				Tags: []*pb.KeyValue{
+					{Key: "lightstep.component_name", Value: &pb.KeyValue_StringValue{StringValue: []byte("svc")}},
+					{Key: "lightstep.hostname", Value: &pb.KeyValue_StringValue{StringValue: []byte("host-1")}},
+					{Key: "lightstep.guid", Value: &pb.KeyValue_StringValue{StringValue: []byte("5a2f0c")}},
+					{Key: "lightstep.tracer_platform", Value: &pb.KeyValue_StringValue{StringValue: []byte("go")}},
+					{Key: "lightstep.tracer_platform_version", Value: &pb.KeyValue_StringValue{StringValue: []byte("go1.22")}},
+					{Key: "lightstep.tracer_version", Value: &pb.KeyValue_StringValue{StringValue: []byte("0.26.0")}},
+					{Key: "lightstep.unknown", Value: &pb.KeyValue_StringValue{StringValue: []byte("x")}},
+				},
+			},
+			Spans: []*pb.Span{
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					Tags: []*pb.KeyValue{
+						{Key: "lightstep.hostname", Value: &pb.KeyValue_StringValue{StringValue: []byte("host-1")}},
+					},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+
+	rAttr := rqOtel.ResourceSpans().At(0).Resource().Attributes()
+	for key, expected := range map[string]string{
+		"host.name":               "host-1",
+		"service.instance.id":     "5a2f0c",
+		"telemetry.sdk.language":  "go",
+		"process.runtime.version": "go1.22",
+		"telemetry.sdk.version":   "0.26.0",
+	} {
+		v, ok := rAttr.Get(key)
+		is.True(ok)
+		is.Equal(v.Str(), expected)
+	}
+	_, ok := rAttr.Get("lightstep.unknown")
+	is.True(!ok)
+	_, ok = rAttr.Get("lightstep.hostname")
+	is.True(!ok)
+
+	attr := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	_, ok = attr.Get("host.name")
+	is.True(!ok)
+}
+
+func TestTransformation_LightstepTags(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   lightstepCommon.LightstepTagsConfig
+		expected map[string]bool
+	}{
+		{
+			name:     "off",
+			config:   lightstepCommon.LightstepTagsConfig{Mode: lightstepCommon.LightstepTagsModeOff},
+			expected: map[string]bool{"lightstep.reporter_id": false, "lightstep.sampled": false},
+		},
+		{
+			name:     "all",
+			config:   lightstepCommon.LightstepTagsConfig{Mode: lightstepCommon.LightstepTagsModeAll},
+			expected: map[string]bool{"lightstep.reporter_id": true, "lightstep.sampled": true},
+		},
+		{
+			name: "allowlist with prefix",
+			config: lightstepCommon.LightstepTagsConfig{
+				Mode:   lightstepCommon.LightstepTagsModeAllowlist,
+				Prefix: "legacy.lightstep.",
+				Keys:   []string{"lightstep.sampled"},
+			},
+			expected: map[string]bool{"legacy.lightstep.reporter_id": false, "legacy.lightstep.sampled": true, "lightstep.sampled": false},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			rq := Request{
+				orig: &pb.ReportRequest{
+					Reporter: &pb.Reporter{
+						Tags: []*pb.KeyValue{
+							{Key: "lightstep.component_name", Value: &pb.KeyValue_StringValue{StringValue: []byte("svc")}},
+							{Key: "lightstep.hostname", Value: &pb.KeyValue_StringValue{StringValue: []byte("host-1")}},
+						},
+					},
+					Spans: []*pb.Span{
+						{
+							SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+							StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+							Tags: []*pb.KeyValue{
+								{Key: "lightstep.reporter_id", Value: &pb.KeyValue_IntValue{IntValue: 42}},
+								{Key: "lightstep.sampled", Value: &pb.KeyValue_BoolValue{BoolValue: true}},
+							},
+						},
+					},
+				},
+				telemetry: initTelemetry(),
+				config:    &lightstepCommon.TransformConfig{LightstepTags: tc.config},
+			}
+			rqOtel, err := rq.ToOtel(context.Background())
+			is.NoErr(err)
+
+			rAttr := rqOtel.ResourceSpans().At(0).Resource().Attributes()
+			v, ok := rAttr.Get("host.name")
+			is.True(ok)
+			is.Equal(v.Str(), "host-1")
+			v, ok = rAttr.Get("lightstep.component_name")
+			is.True(ok)
+			is.Equal(v.Str(), "svc")
+
+			attr := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+			for key, expected := range tc.expected {
+				_, ok := attr.Get(key)
+				is.Equal(ok, expected)
+			}
+		})
+	}
+}
+
+func TestTransformation_ReporterID(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{
+				ReporterId: 11823890906499043596,
+				Tags: []*pb.KeyValue{
+					{Key: "lightstep.component_name", Value: &pb.KeyValue_StringValue{StringValue: []byte("svc")}},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+
+	v, ok := rqOtel.ResourceSpans().At(0).Resource().Attributes().Get("service.instance.id")
+	is.True(ok)
+	is.Equal(v.Str(), "a416e62240d8d10c")
+}
+
+func TestTransformation_ServiceOverride(t *testing.T) {
+	is := is.New(t)
+	span := func(id uint64, tags ...*pb.KeyValue) *pb.Span {
+		return &pb.Span{
+			SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: id},
+			StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+			Tags:           tags,
+		}
+	}
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{
+				Tags: []*pb.KeyValue{
+					{Key: "lightstep.component_name", Value: &pb.KeyValue_StringValue{StringValue: []byte("gateway")}},
+					{Key: "lightstep.hostname", Value: &pb.KeyValue_StringValue{StringValue: []byte("host-1")}},
+				},
+			},
+			Spans: []*pb.Span{
+				span(1),
+				span(2, &pb.KeyValue{Key: "peer.service", Value: &pb.KeyValue_StringValue{StringValue: []byte("orders")}}),
+				span(3, &pb.KeyValue{Key: "service", Value: &pb.KeyValue_StringValue{StringValue: []byte("gateway")}}),
+				span(4,
+					&pb.KeyValue{Key: "service", Value: &pb.KeyValue_StringValue{StringValue: []byte("payments")}},
+					&pb.KeyValue{Key: "peer.service", Value: &pb.KeyValue_StringValue{StringValue: []byte("orders")}},
+				),
+			},
+		},
+		telemetry: initTelemetry(),
+		config: &lightstepCommon.TransformConfig{
+			SemConv:         lightstepCommon.SemConvConfig{Version: "1.26.0"},
+			ServiceOverride: lightstepCommon.ServiceOverrideConfig{Tags: []string{"service", "peer.service"}},
+		},
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(rqOtel.ResourceSpans().Len(), 3)
+
+	for i, expected := range []struct {
+		service string
+		spans   []string
+	}{
+		{service: "gateway", spans: []string{"0000000000000001", "0000000000000003"}},
+		{service: "orders", spans: []string{"0000000000000002"}},
+		{service: "payments", spans: []string{"0000000000000004"}},
+	} {
+		rs := rqOtel.ResourceSpans().At(i)
+		is.Equal(rs.SchemaUrl(), "https://opentelemetry.io/schemas/1.26.0")
+		v, ok := rs.Resource().Attributes().Get("service.name")
+		is.True(ok)
+		is.Equal(v.Str(), expected.service)
+		v, ok = rs.Resource().Attributes().Get("host.name")
+		is.True(ok)
+		is.Equal(v.Str(), "host-1")
+
+		spans := rs.ScopeSpans().At(0).Spans()
+		is.Equal(spans.Len(), len(expected.spans))
+		for j, id := range expected.spans {
+			is.Equal(spans.At(j).SpanID().String(), id)
+		}
+	}
+	_, ok := rqOtel.ResourceSpans().At(2).ScopeSpans().At(0).Spans().At(0).Attributes().Get("service")
+	is.True(ok)
+}
+
+func TestTransformation_SpanKind(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		config     lightstepCommon.SpanKindConfig
+		tags       map[string]string
+		kind       ptrace.SpanKind
+		customKind string
+		keepsTag   bool
+	}{
+		{
+			name:     "known kind",
+			tags:     map[string]string{"span.kind": "server"},
+			kind:     ptrace.SpanKindServer,
+			keepsTag: true,
+		},
+		{
+			name:       "known kind always custom",
+			config:     lightstepCommon.SpanKindConfig{CustomKind: lightstepCommon.CustomKindAlways, StripTag: true},
+			tags:       map[string]string{"span.kind": "server"},
+			kind:       ptrace.SpanKindServer,
+			customKind: "server",
+		},
+		{
+			name:       "unknown kind",
+			tags:       map[string]string{"span.kind": "gateway"},
+			kind:       ptrace.SpanKindUnspecified,
+			customKind: "gateway",
+			keepsTag:   true,
+		},
+		{
+			name:   "unknown kind never custom",
+			config: lightstepCommon.SpanKindConfig{CustomKind: lightstepCommon.CustomKindNever, StripTag: true},
+			tags:   map[string]string{"span.kind": "gateway"},
+			kind:   ptrace.SpanKindUnspecified,
+		},
+		{
+			name:   "inferred client",
+			config: lightstepCommon.SpanKindConfig{Infer: true},
+			tags:   map[string]string{"http.method": "GET", "peer.hostname": "example.org"},
+			kind:   ptrace.SpanKindClient,
+		},
+		{
+			name:   "http method only not inferred",
+			config: lightstepCommon.SpanKindConfig{Infer: true},
+			tags:   map[string]string{"http.method": "GET"},
+			kind:   ptrace.SpanKindUnspecified,
+		},
+		{
+			name: "not inferred",
+			tags: map[string]string{"http.method": "GET"},
+			kind: ptrace.SpanKindUnspecified,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			var tags []*pb.KeyValue
+			for k, v := range tc.tags {
+				tags = append(tags, &pb.KeyValue{Key: k, Value: &pb.KeyValue_StringValue{StringValue: []byte(v)}})
+			}
+			rq := Request{
+				orig: &pb.ReportRequest{
+					Reporter: &pb.Reporter{},
+					Spans: []*pb.Span{
+						{
+							SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+							StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+							Tags:           tags,
+						},
+					},
+				},
+				telemetry: initTelemetry(),
+				config:    &lightstepCommon.TransformConfig{SpanKind: tc.config},
+			}
+			rqOtel, err := rq.ToOtel(context.Background())
+			is.NoErr(err)
+
+			s := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+			is.Equal(s.Kind(), tc.kind)
+			v, ok := s.Attributes().Get("span.custom_kind")
+			is.Equal(ok, tc.customKind != "")
+			if ok {
+				is.Equal(v.Str(), tc.customKind)
+			}
+			_, ok = s.Attributes().Get("span.kind")
+			is.Equal(ok, tc.keepsTag)
+		})
+	}
+}
+
+func TestTransformation_ErrorLogs(t *testing.T) {
+	is := is.New(t)
+	field := func(k, v string) *pb.KeyValue {
+		return &pb.KeyValue{Key: k, Value: &pb.KeyValue_StringValue{StringValue: []byte(v)}}
+	}
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			Spans: []*pb.Span{
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					Tags:           []*pb.KeyValue{{Key: "error", Value: &pb.KeyValue_BoolValue{BoolValue: true}}},
+					Logs: []*pb.Log{
+						{
+							Timestamp: &timestamp.Timestamp{Seconds: 1718207928},
+							Fields:    []*pb.KeyValue{field("event", "cache-miss")},
+						},
+						{
+							Timestamp: &timestamp.Timestamp{Seconds: 1718207928},
+							Fields: []*pb.KeyValue{
+								field("event", "error"),
+								field("error.kind", "TimeoutError"),
+								field("error.object", "TimeoutError: upstream"),
+								field("message", "upstream timed out"),
+								field("stack", "at fetch (app.js:42)"),
+							},
+						},
+						{
+							Timestamp: &timestamp.Timestamp{Seconds: 1718207928},
+							Fields:    []*pb.KeyValue{field("event", "error"), field("error.object", "second")},
+						},
+					},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+
+	s := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(s.Status().Code(), ptrace.StatusCodeError)
+	is.Equal(s.Status().Message(), "upstream timed out")
+	is.Equal(s.Events().At(0).Name(), "cache-miss")
+
+	ev := s.Events().At(1)
+	is.Equal(ev.Name(), "exception")
+	for key, expected := range map[string]string{
+		"exception.type":       "TimeoutError",
+		"exception.message":    "upstream timed out",
+		"exception.stacktrace": "at fetch (app.js:42)",
+	} {
+		v, ok := ev.Attributes().Get(key)
+		is.True(ok)
+		is.Equal(v.Str(), expected)
+	}
+	_, ok := ev.Attributes().Get("error.object")
+	is.True(!ok)
+
+	ev = s.Events().At(2)
+	is.Equal(ev.Name(), "exception")
+	v, ok := ev.Attributes().Get("exception.message")
+	is.True(ok)
+	is.Equal(v.Str(), "second")
+}
+
+func TestTransformation_StatusRules(t *testing.T) {
+	config := lightstepCommon.StatusConfig{HTTP: true, GRPC: true, MessageTags: []string{"error.message"}}
+	for _, tc := range []struct {
+		name    string
+		config  lightstepCommon.StatusConfig
+		tags    []*pb.KeyValue
+		code    ptrace.StatusCode
+		message string
+	}{
+		{
+			name:   "http server 5xx",
+			config: config,
+			tags: []*pb.KeyValue{
+				{Key: "span.kind", Value: &pb.KeyValue_StringValue{StringValue: []byte("server")}},
+				{Key: "http.status_code", Value: &pb.KeyValue_IntValue{IntValue: 503}},
+				{Key: "error.message", Value: &pb.KeyValue_StringValue{StringValue: []byte("backend unavailable")}},
+			},
+			code:    ptrace.StatusCodeError,
+			message: "backend unavailable",
+		},
+		{
+			name:   "http server 4xx",
+			config: config,
+			tags: []*pb.KeyValue{
+				{Key: "span.kind", Value: &pb.KeyValue_StringValue{StringValue: []byte("server")}},
+				{Key: "http.status_code", Value: &pb.KeyValue_StringValue{StringValue: []byte("404")}},
+			},
+			code: ptrace.StatusCodeUnset,
+		},
+		{
+			name:   "http client 4xx disabled",
+			config: config,
+			tags: []*pb.KeyValue{
+				{Key: "span.kind", Value: &pb.KeyValue_StringValue{StringValue: []byte("client")}},
+				{Key: "http.status_code", Value: &pb.KeyValue_IntValue{IntValue: 404}},
+			},
+			code: ptrace.StatusCodeUnset,
+		},
+		{
+			name:   "http client 4xx enabled",
+			config: lightstepCommon.StatusConfig{HTTP: true, HTTPClient4xx: true},
+			tags: []*pb.KeyValue{
+				{Key: "span.kind", Value: &pb.KeyValue_StringValue{StringValue: []byte("client")}},
+				{Key: "http.status_code", Value: &pb.KeyValue_IntValue{IntValue: 404}},
+			},
+			code: ptrace.StatusCodeError,
+		},
+		{
+			name:   "grpc server deadline exceeded",
+			config: config,
+			tags: []*pb.KeyValue{
+				{Key: "span.kind", Value: &pb.KeyValue_StringValue{StringValue: []byte("server")}},
+				{Key: "grpc.status_code", Value: &pb.KeyValue_StringValue{StringValue: []byte("DeadlineExceeded")}},
+			},
+			code: ptrace.StatusCodeError,
+		},
+		{
+			name:   "grpc server not found",
+			config: config,
+			tags: []*pb.KeyValue{
+				{Key: "span.kind", Value: &pb.KeyValue_StringValue{StringValue: []byte("server")}},
+				{Key: "grpc.status_code", Value: &pb.KeyValue_StringValue{StringValue: []byte("NOT_FOUND")}},
+			},
+			code: ptrace.StatusCodeUnset,
+		},
+		{
+			name:   "grpc server internal",
+			config: config,
+			tags: []*pb.KeyValue{
+				{Key: "span.kind", Value: &pb.KeyValue_StringValue{StringValue: []byte("server")}},
+				{Key: "rpc.grpc.status_code", Value: &pb.KeyValue_IntValue{IntValue: 13}},
+			},
+			code: ptrace.StatusCodeError,
+		},
+		{
+			name:   "grpc client not found",
+			config: config,
+			tags: []*pb.KeyValue{
+				{Key: "span.kind", Value: &pb.KeyValue_StringValue{StringValue: []byte("client")}},
+				{Key: "grpc.status_code", Value: &pb.KeyValue_StringValue{StringValue: []byte("NOT_FOUND")}},
+			},
+			code: ptrace.StatusCodeError,
+		},
+		{
+			name:   "error tag message",
+			config: config,
+			tags: []*pb.KeyValue{
+				{Key: "error", Value: &pb.KeyValue_BoolValue{BoolValue: true}},
+				{Key: "error.message", Value: &pb.KeyValue_StringValue{StringValue: []byte("boom")}},
+			},
+			code:    ptrace.StatusCodeError,
+			message: "boom",
+		},
+		{
+			name: "rules disabled",
+			tags: []*pb.KeyValue{
+				{Key: "span.kind", Value: &pb.KeyValue_StringValue{StringValue: []byte("server")}},
+				{Key: "http.status_code", Value: &pb.KeyValue_IntValue{IntValue: 500}},
+			},
+			code: ptrace.StatusCodeUnset,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			rq := Request{
+				orig: &pb.ReportRequest{
+					Reporter: &pb.Reporter{},
+					Spans: []*pb.Span{
+						{
+							SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+							StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+							Tags:           tc.tags,
+						},
+					},
+				},
+				telemetry: initTelemetry(),
+				config:    &lightstepCommon.TransformConfig{Status: tc.config},
+			}
+			rqOtel, err := rq.ToOtel(context.Background())
+			is.NoErr(err)
+
+			s := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+			is.Equal(s.Status().Code(), tc.code)
+			is.Equal(s.Status().Message(), tc.message)
+		})
+	}
+}
+
+func TestTransformation_Validation(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{
+				Tags: []*pb.KeyValue{
+					{Key: "lightstep.component_name", Value: &pb.KeyValue_StringValue{StringValue: []byte("svc")}},
+				},
+			},
+			Spans: []*pb.Span{
+				{
+					OperationName:  "zero-trace-id",
+					SpanContext:    &pb.SpanContext{TraceId: 0, SpanId: 1},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+				},
+				{
+					OperationName:  "self-parent",
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 2},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+					References: []*pb.Reference{
+						{Relationship: pb.Reference_CHILD_OF, SpanContext: &pb.SpanContext{TraceId: 1, SpanId: 2}},
+					},
+				},
+				{
+					SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 3},
+					StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+				},
+				{
+					OperationName: "missing-timestamp",
+					SpanContext:   &pb.SpanContext{TraceId: 1, SpanId: 4},
+				},
+			},
+		},
+		telemetry: initTelemetry(),
+		config: &lightstepCommon.TransformConfig{
+			Validation: lightstepCommon.ValidationConfig{
+				ZeroTraceID:      lightstepCommon.ValidationActionDrop,
+				SelfParent:       lightstepCommon.ValidationActionRepair,
+				EmptyName:        lightstepCommon.ValidationActionRepair,
+				MissingTimestamp: lightstepCommon.ValidationActionFlag,
+			},
+		},
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+
+	spans := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	is.Equal(spans.Len(), 3)
+
+	is.Equal(spans.At(0).Name(), "self-parent")
+	is.True(spans.At(0).ParentSpanID().IsEmpty())
+
+	is.Equal(spans.At(1).Name(), lightstepCommon.RepairedSpanName)
+
+	flags, ok := spans.At(2).Attributes().Get(lightstepCommon.ValidationFlagAttribute)
+	is.True(ok)
+	is.Equal(flags.Slice().AsRaw(), []any{"missing_timestamp"})
+
+	for rule, expected := range map[lightstepCommon.ValidationRule]int64{
+		lightstepCommon.RuleZeroTraceID:      1,
+		lightstepCommon.RuleSelfParent:       1,
+		lightstepCommon.RuleEmptyName:        1,
+		lightstepCommon.RuleMissingTimestamp: 1,
+	} {
+		is.Equal(rq.telemetry.InvalidSpans[string(rule)], expected)
+	}
+}
+
+func TestTransformation_ValidationAfterClockCorrection(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			Spans: []*pb.Span{
+				{
+					OperationName: "missing-timestamp",
+					SpanContext:   &pb.SpanContext{TraceId: 1, SpanId: 1},
+				},
+			},
+			TimestampOffsetMicros: time.Hour.Microseconds(),
+		},
+		telemetry: initTelemetry(),
+		config: &lightstepCommon.TransformConfig{
+			ClockCorrection: lightstepCommon.ClockCorrectionConfig{Enabled: true},
+			Validation: lightstepCommon.ValidationConfig{
+				MissingTimestamp: lightstepCommon.ValidationActionRepair,
+			},
+		},
+	}
+	rqOtel, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+
+	span := rqOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.True(time.Since(span.StartTimestamp().AsTime()).Abs() < time.Minute)
+	is.Equal(span.EndTimestamp(), span.StartTimestamp())
+}
+
+func TestTransformation_NilReportRequest(t *testing.T) {
+	is := is.New(t)
+	rq := Request{telemetry: initTelemetry()}
+	_, err := rq.ToOtel(context.Background())
+	is.Equal(err, lightstepCommon.ErrNoReportRequest)
+}
+
+func TestTransformation_NilReporter(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Spans: []*pb.Span{
+				{SpanContext: &pb.SpanContext{TraceId: 1, SpanId: 1}},
+			},
+		},
+		telemetry: initTelemetry(),
+	}
+	res, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(res.ServiceName, "")
+	is.Equal(res.SpanCount(), 1)
+}
+
+func TestTransformation_NilSpanContext(t *testing.T) {
+	is := is.New(t)
+	rq := Request{
+		orig: &pb.ReportRequest{
+			Reporter: &pb.Reporter{},
+			Spans: []*pb.Span{
+				nil,
+				{
+					Tags: []*pb.KeyValue{nil},
+					Logs: []*pb.Log{nil, {Fields: []*pb.KeyValue{nil}}},
+					References: []*pb.Reference{
+						nil,
+						{Relationship: pb.Reference_CHILD_OF},
+					},
+				},
+			},
+			InternalMetrics: &pb.InternalMetrics{Logs: []*pb.Log{nil}},
+		},
+		telemetry: initTelemetry(),
+	}
+	res, err := rq.ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(res.SpanCount(), 1)
+	is.Equal(res.Logs.LogRecordCount(), 0)
+
+	s := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.True(s.SpanID().IsEmpty())
+	is.True(s.ParentSpanID().IsEmpty())
+	is.Equal(s.Links().Len(), 0)
+	is.Equal(s.Events().Len(), 1)
+}
@@ -0,0 +1,134 @@
+package lightstep_thrift
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	lightstepCommon "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_common"
+	"github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_thrift/collectorthrift"
+)
+
+// Otel semantic conventions attributes LogRecord fields are mapped onto
+const (
+	attrCodeFilepath        = "code.filepath"
+	attrCodeLineno          = "code.lineno"
+	attrExceptionStacktrace = lightstepCommon.ExceptionStacktraceAttribute
+	attrExceptionMessage    = lightstepCommon.ExceptionMessageAttribute
+	attrExceptionType       = lightstepCommon.ExceptionTypeAttribute
+	attrThreadID            = "thread.id"
+	attrPayload             = "payload"
+	attrMessage             = "message"
+	attrLevel               = "level"
+	attrSpanGUID            = "lightstep.span_guid"
+
+	exceptionEventName = lightstepCommon.ExceptionEventName
+)
+
+// logRecordAttributes puts LogRecord fields and its rich fields into attributes, collecting the keys of the ones
+// with invalid UTF8 into nonUtf8Keys
+func (tr *Request) logRecordAttributes(log *collectorthrift.LogRecord, attr pcommon.Map, nonUtf8Keys *[]string) {
+	if keys, err := tr.kvToAttr(log.GetFields(), &attr, false); err != nil {
+		*nonUtf8Keys = append(*nonUtf8Keys, *keys...)
+	}
+	tr.config.TypeCoercion.Coerce(attr, tr.config.TypeCoercion.Infer)
+
+	if log.IsSetFilename() {
+		tr.putStr(attr, attrCodeFilepath, log.GetFilename(), nonUtf8Keys)
+	}
+	if log.IsSetLineNumber() {
+		attr.PutInt(attrCodeLineno, log.GetLineNumber())
+	}
+	if len(log.GetStackFrames()) > 0 {
+		tr.putStr(attr, attrExceptionStacktrace, strings.Join(log.GetStackFrames(), "\n"), nonUtf8Keys)
+	}
+	if log.IsSetThreadId() {
+		attr.PutInt(attrThreadID, log.GetThreadId())
+	}
+	if log.IsSetPayloadJson() {
+		if utf8.ValidString(log.GetPayloadJson()) {
+			tr.config.JSONValue.PutJSONValue(attr, attrPayload, log.GetPayloadJson())
+		} else {
+			tr.putStr(attr, attrPayload, log.GetPayloadJson(), nonUtf8Keys)
+		}
+	}
+}
+
+// convertLogRecord converts thrift LogRecord into Otel log record, records of the report spans are correlated
+// by trace and span ids, the span guid of unknown spans is kept in attrSpanGUID
+func (tr *Request) convertLogRecord(log *collectorthrift.LogRecord, lr plog.LogRecord, traceIDs map[string]pcommon.TraceID) {
+	if log.IsSetTimestampMicros() {
+		lr.SetTimestamp(tr.convertTimestamp(log.TimestampMicros))
+	}
+
+	var nonUtf8Keys []string
+	tr.logRecordAttributes(log, lr.Attributes(), &nonUtf8Keys)
+	lr.SetDroppedAttributesCount(tr.droppedNonUtf8(&nonUtf8Keys))
+
+	if traceID, ok := traceIDs[log.GetSpanGuid()]; ok && log.IsSetSpanGuid() {
+		lr.SetTraceID(traceID)
+		lr.SetSpanID(tr.convertSpanID(log.GetSpanGuid()))
+	} else if log.GetSpanGuid() != "" {
+		lr.Attributes().PutStr(attrSpanGUID, log.GetSpanGuid())
+	}
+
+	if log.IsSetMessage() {
+		lr.Body().SetStr(tr.validString(attrMessage, log.GetMessage()))
+	}
+	if log.IsSetStableName() {
+		lr.SetEventName(tr.validString("stable_name", log.GetStableName()))
+	}
+	if log.IsSetLevel() {
+		level := tr.validString(attrLevel, log.GetLevel())
+		lr.SetSeverityText(level)
+		lr.SetSeverityNumber(lightstepCommon.ParseSeverity(level))
+	}
+	if log.GetErrorFlag() && lr.SeverityNumber() < plog.SeverityNumberError {
+		lr.SetSeverityNumber(plog.SeverityNumberError)
+	}
+}
+
+// convertSpanLog converts thrift LogRecord of a span into span event, the event name falls back
+// to StableName and Message, error flagged records and OpenTracing error logs become exception events
+func (tr *Request) convertSpanLog(log *collectorthrift.LogRecord, ev ptrace.SpanEvent) {
+	if log.IsSetTimestampMicros() {
+		ev.SetTimestamp(tr.convertTimestamp(log.TimestampMicros))
+	}
+
+	evAttr := ev.Attributes()
+	var nonUtf8Keys []string
+	tr.logRecordAttributes(log, evAttr, &nonUtf8Keys)
+
+	name := log.GetStableName()
+	if evName, ok := evAttr.Get("event"); ok {
+		name = evName.AsString()
+		evAttr.Remove("event")
+	}
+	if log.IsSetLevel() {
+		tr.putStr(evAttr, attrLevel, log.GetLevel(), &nonUtf8Keys)
+	}
+
+	switch {
+	case log.GetErrorFlag():
+		if log.IsSetStableName() {
+			tr.putStr(evAttr, attrExceptionType, log.GetStableName(), &nonUtf8Keys)
+		}
+		if log.IsSetMessage() {
+			tr.putStr(evAttr, attrExceptionMessage, log.GetMessage(), &nonUtf8Keys)
+		} else if name != "" {
+			tr.putStr(evAttr, attrExceptionMessage, name, &nonUtf8Keys)
+		}
+		name = exceptionEventName
+	case name == "":
+		name = log.GetMessage()
+	case log.IsSetMessage():
+		tr.putStr(evAttr, attrMessage, log.GetMessage(), &nonUtf8Keys)
+	}
+
+	ev.SetName(tr.validString("event_name", name))
+	lightstepCommon.ConvertErrorEvent(ev)
+	ev.SetDroppedAttributesCount(tr.droppedNonUtf8(&nonUtf8Keys))
+}
@@ -8,6 +8,7 @@ import (
 	"go.uber.org/zap"
 	"time"
 
+	lightstepCommon "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_common"
 	"github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_thrift/collectorthrift"
 	"github.com/zalando/otelcol-lightstep-receiver/internal/telemetry"
 )
@@ -18,7 +19,10 @@ type ThriftServerReportRequest struct {
 	context          context.Context
 	obsreport        *receiverhelper.ObsReport
 	nextTraces       consumer.Traces
+	nextMetrics      consumer.Metrics
+	nextLogs         consumer.Logs
 	telemetry        *telemetry.Telemetry
+	transformConfig  *lightstepCommon.TransformConfig
 }
 
 func (tsr *ThriftServerReportRequest) getFormatFromContext() string {
@@ -33,7 +37,7 @@ func (tsr *ThriftServerReportRequest) getFormatFromContext() string {
 func (tsr *ThriftServerReportRequest) Report(auth *collectorthrift.Auth, request *collectorthrift.ReportRequest) (r *collectorthrift.ReportResponse, err error) {
 	ctx := tsr.obsreport.StartTracesOp(tsr.context)
 
-	tr := NewThriftRequest(auth, request, tsr.telemetry)
+	tr := NewThriftRequest(auth, request, tsr.telemetry, tsr.transformConfig)
 
 	otelTr, err := tr.ToOtel(ctx)
 
@@ -56,9 +60,22 @@ func (tsr *ThriftServerReportRequest) Report(auth *collectorthrift.Auth, request
 	clientInfo.Metadata = client.NewMetadata(map[string][]string{"lightstep-access-token": {otelTr.AccessToken}})
 	ctx = client.NewContext(ctx, clientInfo)
 
-	err = tsr.nextTraces.ConsumeTraces(ctx, otelTr.Traces)
+	spanCount := 0
+	if tsr.nextTraces != nil {
+		err = tsr.nextTraces.ConsumeTraces(ctx, otelTr.Traces)
+		spanCount = otelTr.Traces.SpanCount()
+	}
+	tsr.obsreport.EndTracesOp(ctx, tsr.getFormatFromContext(), spanCount, err)
 
-	tsr.obsreport.EndTracesOp(ctx, tsr.getFormatFromContext(), otelTr.Traces.SpanCount(), err)
+	if err == nil {
+		err = lightstepCommon.ConsumeMetrics(ctx, tsr.nextMetrics, tsr.obsreport, tsr.getFormatFromContext(), otelTr.Metrics)
+	}
+	if err == nil {
+		err = lightstepCommon.ConsumeLogs(ctx, tsr.nextLogs, tsr.obsreport, tsr.getFormatFromContext(), otelTr.Logs)
+	}
+	if tsr.nextLogs == nil && otelTr.OrphanLogRecords > 0 {
+		tsr.telemetry.IncrementDroppedLogRecords(transport, otelTr.OrphanLogRecords)
+	}
 	return tsr.newReportResponse(err), err
 }
 
@@ -27,6 +27,7 @@ import (
 
 	"github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_thrift/thrift_0_9_2/lib/go/thrift"
 
+	lightstepCommon "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_common"
 	"github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_thrift/collectorthrift"
 	"github.com/zalando/otelcol-lightstep-receiver/internal/telemetry"
 )
@@ -41,25 +42,34 @@ type ThriftServer struct {
 	settings  *receiver.Settings
 	obsreport *receiverhelper.ObsReport
 
-	nextTraces consumer.Traces
-	telemetry  *telemetry.Telemetry
+	nextTraces      consumer.Traces
+	nextMetrics     consumer.Metrics
+	nextLogs        consumer.Logs
+	telemetry       *telemetry.Telemetry
+	transformConfig *lightstepCommon.TransformConfig
 
 	shutdownWG sync.WaitGroup
 }
 
 func NewServer(
 	config *confighttp.ServerConfig,
+	transformConfig *lightstepCommon.TransformConfig,
 	set *receiver.Settings,
 	nextTraces consumer.Traces,
+	nextMetrics consumer.Metrics,
+	nextLogs consumer.Logs,
 	obsreport *receiverhelper.ObsReport,
 	telemetry *telemetry.Telemetry,
 ) *ThriftServer {
 	return &ThriftServer{
-		config:     config,
-		settings:   set,
-		obsreport:  obsreport,
-		nextTraces: nextTraces,
-		telemetry:  telemetry,
+		config:          config,
+		settings:        set,
+		obsreport:       obsreport,
+		nextTraces:      nextTraces,
+		nextMetrics:     nextMetrics,
+		nextLogs:        nextLogs,
+		telemetry:       telemetry,
+		transformConfig: transformConfig,
 	}
 }
 
@@ -161,7 +171,10 @@ func (ts *ThriftServer) HandleThriftBinaryRequest(w http.ResponseWriter, rq *htt
 		context:          ctx,
 		obsreport:        ts.obsreport,
 		nextTraces:       ts.nextTraces,
+		nextMetrics:      ts.nextMetrics,
+		nextLogs:         ts.nextLogs,
 		telemetry:        ts.telemetry,
+		transformConfig:  ts.transformConfig,
 		receiveTimestamp: time.Now().UnixMicro(),
 	}
 
@@ -203,7 +216,10 @@ func (ts *ThriftServer) HandleThriftJSONRequestV0(w http.ResponseWriter, rq *htt
 		context:          ctx,
 		obsreport:        ts.obsreport,
 		nextTraces:       ts.nextTraces,
+		nextMetrics:      ts.nextMetrics,
+		nextLogs:         ts.nextLogs,
 		telemetry:        ts.telemetry,
+		transformConfig:  ts.transformConfig,
 		receiveTimestamp: time.Now().UnixMicro(),
 	}
 
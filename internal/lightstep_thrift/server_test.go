@@ -0,0 +1,170 @@
+package lightstep_thrift
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+
+	lightstepCommon "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_common"
+	"github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_thrift/collectorthrift"
+	"github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_thrift/thrift_0_9_2/lib/go/thrift"
+	"github.com/zalando/otelcol-lightstep-receiver/internal/telemetry"
+)
+
+func initServer(t *testing.T, sink *consumertest.TracesSink) *ThriftServer {
+	set := receivertest.NewNopSettings(component.MustNewType("lightstepreceiver"))
+	obsrep, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             set.ID,
+		Transport:              transport,
+		ReceiverCreateSettings: set,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tel := &telemetry.Telemetry{}
+	logger, _ := zap.NewDevelopment()
+	tel.Init(receiver.Settings{
+		TelemetrySettings: component.TelemetrySettings{
+			TracerProvider: noop.NewTracerProvider(),
+			Logger:         logger,
+		},
+	})
+	return NewServer(&confighttp.ServerConfig{}, &lightstepCommon.TransformConfig{}, &set, sink, nil, nil, obsrep, tel)
+}
+
+func spanAttributes(sink *consumertest.TracesSink) pcommon.Map {
+	return sink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+}
+
+func TestServer_BinaryNonUTF8(t *testing.T) {
+	is := is.New(t)
+	sink := &consumertest.TracesSink{}
+	ts := initServer(t, sink)
+
+	buf := thrift.NewTMemoryBuffer()
+	oprot := thrift.NewTBinaryProtocolTransport(buf)
+	is.NoErr(oprot.WriteMessageBegin("Report", thrift.CALL, 1))
+	args := collectorthrift.ReportArgs{
+		Auth: &collectorthrift.Auth{AccessToken: ptr("access-token")},
+		Request: &collectorthrift.ReportRequest{
+			Runtime: &collectorthrift.Runtime{
+				Attrs: []*collectorthrift.KeyValue{
+					{Key: "lightstep.component_name", Value: "svc"},
+					{Key: "runtime.invalid", Value: "bad\xff"},
+				},
+			},
+			SpanRecords: []*collectorthrift.SpanRecord{
+				{
+					SpanGuid:       ptr("1c5994087c3bf8be"),
+					TraceGuid:      ptr("1c5994087c3bf8be"),
+					SpanName:       ptr("op"),
+					OldestMicros:   ptr(int64(1000)),
+					YoungestMicros: ptr(int64(2000)),
+					Attributes: []*collectorthrift.KeyValue{
+						{Key: "valid", Value: "ok"},
+						{Key: "invalid", Value: "\xc3\x28"},
+						{Key: "invalid.too", Value: "\xff"},
+					},
+				},
+			},
+		},
+	}
+	is.NoErr(args.Write(oprot))
+	is.NoErr(oprot.WriteMessageEnd())
+
+	rec := httptest.NewRecorder()
+	ts.HandleThriftBinaryRequest(rec, httptest.NewRequest(http.MethodPost, "/_rpc/v1/reports/binary", bytes.NewReader(buf.Bytes())))
+	is.Equal(rec.Code, http.StatusOK)
+
+	is.Equal(len(sink.AllTraces()), 1)
+	attr := spanAttributes(sink)
+	_, ok := attr.Get("invalid")
+	is.True(!ok)
+	_, ok = attr.Get("invalid.too")
+	is.True(!ok)
+	v, ok := attr.Get("valid")
+	is.True(ok)
+	is.Equal(v.Str(), "ok")
+
+	is.Equal(sink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).DroppedAttributesCount(), uint32(2))
+
+	resource := sink.AllTraces()[0].ResourceSpans().At(0).Resource()
+	_, ok = resource.Attributes().Get("runtime.invalid")
+	is.True(!ok)
+	is.Equal(resource.DroppedAttributesCount(), uint32(1))
+	is.Equal(ts.telemetry.NonUTF8Attributes[transport], int64(3))
+}
+
+func TestServer_JSONNonUTF8(t *testing.T) {
+	is := is.New(t)
+	sink := &consumertest.TracesSink{}
+	ts := initServer(t, sink)
+
+	body := []byte(`{
+		"runtime": {"group_name": "svc", "attrs": [{"Key": "runtime.invalid", "Value": "bad` + "\xff" + `"}]},
+		"span_records": [{
+			"span_guid": "1c5994087c3bf8be",
+			"trace_guid": "1c5994087c3bf8be",
+			"span_name": "op",
+			"oldest_micros": 1000,
+			"youngest_micros": 2000,
+			"attributes": [{"Key": "valid", "Value": "ok"}, {"Key": "invalid", "Value": "` + "\xc3\x28" + `"}]
+		}]
+	}`)
+
+	rec := httptest.NewRecorder()
+	ts.HandleThriftJSONRequestV0(rec, httptest.NewRequest(http.MethodPost, "/api/v0/reports", bytes.NewReader(body)))
+	is.Equal(rec.Code, http.StatusOK)
+
+	// json decoding already replaces invalid sequences with U+FFFD, so nothing is left to drop or count
+	is.Equal(len(sink.AllTraces()), 1)
+	attr := spanAttributes(sink)
+	v, ok := attr.Get("invalid")
+	is.True(ok)
+	is.Equal(v.Str(), "\uFFFD(")
+	v, ok = attr.Get("valid")
+	is.True(ok)
+	is.Equal(v.Str(), "ok")
+	is.Equal(sink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).DroppedAttributesCount(), uint32(0))
+
+	resource := sink.AllTraces()[0].ResourceSpans().At(0).Resource()
+	v, ok = resource.Attributes().Get("runtime.invalid")
+	is.True(ok)
+	is.Equal(v.Str(), "bad\uFFFD")
+	is.Equal(ts.telemetry.NonUTF8Attributes[transport], int64(0))
+}
+
+func TestServer_OrphanLogRecordsDropped(t *testing.T) {
+	is := is.New(t)
+	sink := &consumertest.TracesSink{}
+	ts := initServer(t, sink)
+
+	body := []byte(`{
+		"runtime": {"group_name": "svc"},
+		"span_records": [{"span_guid": "1c5994087c3bf8be", "trace_guid": "1c5994087c3bf8be", "oldest_micros": 1000, "youngest_micros": 2000}],
+		"log_records": [
+			{"timestamp_micros": 1500, "span_guid": "1c5994087c3bf8be", "message": "attached"},
+			{"timestamp_micros": 1500, "span_guid": "00000000000000aa", "message": "orphan"},
+			{"timestamp_micros": 1500, "message": "report"}
+		]
+	}`)
+
+	rec := httptest.NewRecorder()
+	ts.HandleThriftJSONRequestV0(rec, httptest.NewRequest(http.MethodPost, "/api/v0/reports", bytes.NewReader(body)))
+	is.Equal(rec.Code, http.StatusOK)
+
+	is.Equal(sink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Events().Len(), 1)
+	is.Equal(ts.telemetry.DroppedLogRecords[transport], int64(2))
+}
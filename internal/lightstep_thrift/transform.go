@@ -3,11 +3,14 @@ package lightstep_thrift
 import (
 	"context"
 	"encoding/hex"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	lightstepConstants "github.com/lightstep/lightstep-tracer-go/constants"
 	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
@@ -17,28 +20,78 @@ import (
 	"github.com/zalando/otelcol-lightstep-receiver/internal/telemetry"
 )
 
+const errorFlagStatusMessage = "error flag set by tracer"
+
 type Request struct {
 	auth      *collectorthrift.Auth
 	orig      *collectorthrift.ReportRequest
 	telemetry *telemetry.Telemetry
+	config    *lightstepCommon.TransformConfig
+
+	serviceName string
 }
 
-func NewThriftRequest(auth *collectorthrift.Auth, orig *collectorthrift.ReportRequest, t *telemetry.Telemetry) *Request {
+func NewThriftRequest(auth *collectorthrift.Auth, orig *collectorthrift.ReportRequest, t *telemetry.Telemetry, config *lightstepCommon.TransformConfig) *Request {
 	return &Request{
 		auth:      auth,
 		orig:      orig,
 		telemetry: t,
+		config:    config,
 	}
 }
 
-func (tr *Request) kvToAttr(kv []*collectorthrift.KeyValue, p *pcommon.Map) {
+func (tr *Request) reportNonUtf8(keys *[]string) {
+	lightstepCommon.ReportNonUTF8(tr.telemetry, transport, tr.serviceName, *keys)
+}
+
+// kvToAttr puts key values into attributes, resource is set for Runtime attrs to map well-known lightstep tags
+func (tr *Request) kvToAttr(kv []*collectorthrift.KeyValue, p *pcommon.Map, resource bool) (*[]string, error) {
 	res := *p
+	var nonUtf8Keys []string
 	for _, t := range kv {
-		if strings.HasPrefix(t.GetKey(), "lightstep.") && t.GetKey() != lightstepConstants.ComponentNameKey {
+		key, ok := tr.config.LightstepTags.AttributeKey(t.GetKey(), resource)
+		if !ok {
 			continue
 		}
-		res.PutStr(t.GetKey(), t.GetValue())
+		if _, exists := res.Get(key); exists && key != t.GetKey() {
+			continue
+		}
+		tr.putStr(res, key, t.GetValue(), &nonUtf8Keys)
+	}
+	if len(nonUtf8Keys) > 0 {
+		return &nonUtf8Keys, lightstepCommon.ErrNonUTF8Attribute
+	}
+	return nil, nil
+}
+
+// putStr puts the string attribute, attributes with invalid UTF8 are put according to the non UTF8 mode
+// and their keys collected into nonUtf8Keys
+func (tr *Request) putStr(m pcommon.Map, key, value string, nonUtf8Keys *[]string) {
+	if !utf8.ValidString(key) || !utf8.ValidString(value) {
+		*nonUtf8Keys = append(*nonUtf8Keys, key)
+		tr.config.NonUTF8.PutValue(m, key, []byte(value))
+		return
+	}
+	m.PutStr(key, value)
+}
+
+// validString replaces and reports invalid UTF8 sequences of span and event names and of log record fields
+// which aren't put as attributes
+func (tr *Request) validString(key, value string) string {
+	if utf8.ValidString(value) {
+		return value
+	}
+	tr.reportNonUtf8(&[]string{key})
+	return strings.ToValidUTF8(value, "�")
+}
+
+// droppedNonUtf8 reports the keys of attributes with invalid UTF8 and returns the number of the dropped ones
+func (tr *Request) droppedNonUtf8(nonUtf8Keys *[]string) uint32 {
+	if len(*nonUtf8Keys) == 0 {
+		return 0
 	}
+	tr.reportNonUtf8(nonUtf8Keys)
+	return tr.config.NonUTF8.Dropped(nonUtf8Keys)
 }
 
 func (tr *Request) ToOtel(ctx context.Context) (*lightstepCommon.ProjectTraces, error) {
@@ -46,6 +99,14 @@ func (tr *Request) ToOtel(ctx context.Context) (*lightstepCommon.ProjectTraces,
 	defer span.End()
 
 	result := &lightstepCommon.ProjectTraces{}
+	if tr.config == nil {
+		tr.config = &lightstepCommon.TransformConfig{}
+	}
+	if tr.orig == nil {
+		span.SetStatus(codes.Error, lightstepCommon.ErrNoReportRequest.Error())
+		return nil, lightstepCommon.ErrNoReportRequest
+	}
+	tr.sanitize()
 
 	if tr.auth == nil || tr.auth.AccessToken == nil {
 		span.SetStatus(codes.Error, lightstepCommon.ErrNoAccessToken.Error())
@@ -55,9 +116,10 @@ func (tr *Request) ToOtel(ctx context.Context) (*lightstepCommon.ProjectTraces,
 
 	data := ptrace.NewTraces()
 	rs := data.ResourceSpans().AppendEmpty()
+	rs.SetSchemaUrl(tr.config.SemConv.SchemaURL())
 	rAttr := rs.Resource().Attributes()
 
-	tr.kvToAttr(tr.orig.Runtime.Attrs, &rAttr)
+	nonUtf8Keys, err := tr.kvToAttr(tr.orig.Runtime.GetAttrs(), &rAttr, true)
 	serviceName, ok := rAttr.Get(lightstepConstants.ComponentNameKey)
 	if ok {
 		result.ServiceName = serviceName.Str()
@@ -65,34 +127,74 @@ func (tr *Request) ToOtel(ctx context.Context) (*lightstepCommon.ProjectTraces,
 	} else {
 		span.SetStatus(codes.Error, lightstepCommon.ErrNoServiceName.Error())
 	}
+	tr.serviceName = result.ServiceName
+	lightstepCommon.PutRuntimeIdentity(rAttr, tr.orig.Runtime.GetGuid(), tr.orig.Runtime.GetStartMicros())
+	if tr.config.Runtime.GroupNameAttribute != "" && tr.orig.Runtime.IsSetGroupName() {
+		rAttr.PutStr(tr.config.Runtime.GroupNameAttribute, tr.orig.Runtime.GetGroupName())
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, "non-utf8-keys")
+		tr.reportNonUtf8(nonUtf8Keys)
+		rs.Resource().SetDroppedAttributesCount(tr.config.NonUTF8.Dropped(nonUtf8Keys))
+	}
 
 	if tr.orig.InternalMetrics != nil {
-		for _, m := range tr.orig.InternalMetrics.Counts {
-			if m.Name == "spans.dropped" {
+		for _, m := range tr.orig.InternalMetrics.GetCounts() {
+			if m.GetName() == "spans.dropped" {
 				result.ClientSpansDropped = m.GetInt64Value()
 				break
 			}
 		}
 	}
+	result.Metrics = tr.convertInternalMetrics(rAttr)
+	logs := lightstepCommon.NewTracerLogs(rAttr)
+	spanLogs := tr.spanLogRecords()
+	traceIDs := tr.spanTraceIDs()
+	for _, log := range tr.orig.GetLogRecords() {
+		if _, ok := spanLogs[log.GetSpanGuid()]; ok && log.IsSetSpanGuid() {
+			spanLogs[log.GetSpanGuid()] = append(spanLogs[log.GetSpanGuid()], log)
+			continue
+		}
+		tr.convertLogRecord(log, logs.AppendEmpty(), traceIDs)
+		result.OrphanLogRecords++
+	}
+	for _, log := range tr.orig.GetInternalLogs() {
+		lr := logs.AppendEmpty()
+		tr.convertLogRecord(log, lr, traceIDs)
+		lr.Attributes().PutBool(lightstepCommon.InternalLogAttribute, true)
+	}
+
+	clockOffset := tr.config.ClockCorrection.Offset(tr.orig.GetTimestampOffsetMicros())
+	logs.ApplyClockCorrection(clockOffset)
 
 	ss := rs.ScopeSpans().AppendEmpty()
+	runtimes := map[string]ptrace.ScopeSpans{"": ss, tr.orig.Runtime.GetGuid(): ss}
 	for _, span := range tr.orig.SpanRecords {
-		s := ss.Spans().AppendEmpty()
-		s.SetName(span.GetSpanName())
+		s := ptrace.NewSpan()
+		s.SetName(tr.validString("span_name", span.GetSpanName()))
 
 		s.SetSpanID(tr.convertSpanID(span.GetSpanGuid()))
-		s.SetTraceID(tr.convertTraceID(span.GetTraceGuid()))
+		s.SetTraceID(lightstepCommon.WithTraceIDUpper(tr.convertTraceID(span.GetTraceGuid()), tr.traceIDUpper(span.Attributes)))
 
 		s.SetStartTimestamp(tr.convertTimestamp(span.OldestMicros))
 		s.SetEndTimestamp(tr.convertTimestamp(span.YoungestMicros))
 
 		attr := s.Attributes()
-		tr.kvToAttr(span.Attributes, &attr)
+		if nonUtf8Keys, err = tr.kvToAttr(span.Attributes, &attr, false); err != nil {
+			tr.reportNonUtf8(nonUtf8Keys)
+			s.SetDroppedAttributesCount(tr.config.NonUTF8.Dropped(nonUtf8Keys))
+		}
+		tr.config.RemoveTraceIDUpperTag(attr)
 
 		if parentSpanID, ok := attr.Get("parent_span_guid"); ok {
 			s.SetParentSpanID(tr.convertSpanID(parentSpanID.Str()))
 			attr.Remove("parent_span_guid")
 		}
+		tr.config.TypeCoercion.Coerce(attr, tr.config.TypeCoercion.Infer)
+		if nonUtf8Keys := tr.convertJoinIDs(span.GetJoinIds(), s); len(nonUtf8Keys) > 0 {
+			tr.reportNonUtf8(&nonUtf8Keys)
+			s.SetDroppedAttributesCount(s.DroppedAttributesCount() + tr.config.NonUTF8.Dropped(&nonUtf8Keys))
+		}
 
 		if value, ok := attr.Get("error"); ok {
 			if lightstepCommon.IsErrorAttributeValueActuallyError(value) {
@@ -101,35 +203,248 @@ func (tr *Request) ToOtel(ctx context.Context) (*lightstepCommon.ProjectTraces,
 			}
 		}
 
-		if spanKind, ok := attr.Get("span.kind"); ok {
-			otelSpanKind, stringSpanKind := lightstepCommon.ParseSpanKindAttributeValue(spanKind)
-			s.SetKind(otelSpanKind)
-			if otelSpanKind == ptrace.SpanKindUnspecified {
-				attr.PutStr("span.custom_kind", stringSpanKind)
-			}
-		}
+		tr.config.SpanKind.Apply(s)
+		tr.config.Status.Apply(s)
 
-		for _, log := range span.LogRecords {
-			ev := s.Events().AppendEmpty()
+		logRecords := append(slices.Clone(span.GetLogRecords()), spanLogs[span.GetSpanGuid()]...)
+		for _, log := range logRecords {
+			tr.convertSpanLog(log, s.Events().AppendEmpty())
+		}
+		lightstepCommon.SetExceptionStatusMessage(s)
+		tr.applyErrorFlags(span.GetErrorFlag(), logRecords, s)
 
-			ev.SetTimestamp(tr.convertTimestamp(log.TimestampMicros))
+		lightstepCommon.ApplyClockCorrection(s, clockOffset)
+		if !tr.config.Validation.Check(s, func(rule lightstepCommon.ValidationRule) {
+			tr.telemetry.IncrementInvalidSpans(string(rule), tr.serviceName, 1)
+		}) {
+			continue
+		}
+		tr.config.SemConv.TranslateSpan(s)
 
-			evAttr := ev.Attributes()
-			tr.kvToAttr(log.Fields, &evAttr)
-			if evName, ok := evAttr.Get("event"); ok {
-				ev.SetName(evName.Str())
-				evAttr.Remove("event")
-			}
+		if tr.config.Logs.SpanEvents {
+			logs.AppendSpanEvents(s)
 		}
+		s.MoveTo(tr.runtimeScopeSpans(data, runtimes, span).Spans().AppendEmpty())
 	}
 
+	tr.config.ServiceOverride.Apply(data)
 	result.Traces = data
+	result.Logs = logs.Logs()
 	return result, nil
 }
 
+// sanitize defaults the missing Runtime and drops nil elements of the report lists, as the generated
+// thrift getters aren't nil safe
+func (tr *Request) sanitize() {
+	if tr.orig.Runtime == nil {
+		tr.orig.Runtime = collectorthrift.NewRuntime()
+	}
+	tr.orig.Runtime.Attrs = withoutNil(tr.orig.Runtime.Attrs)
+	tr.orig.Counters = withoutNil(tr.orig.Counters)
+	if tr.orig.InternalMetrics != nil {
+		tr.orig.InternalMetrics.Counts = withoutNil(tr.orig.InternalMetrics.Counts)
+		tr.orig.InternalMetrics.Gauges = withoutNil(tr.orig.InternalMetrics.Gauges)
+	}
+
+	sanitizeLogs := func(logs []*collectorthrift.LogRecord) []*collectorthrift.LogRecord {
+		logs = withoutNil(logs)
+		for _, log := range logs {
+			log.Fields = withoutNil(log.Fields)
+		}
+		return logs
+	}
+	tr.orig.LogRecords = sanitizeLogs(tr.orig.LogRecords)
+	tr.orig.InternalLogs = sanitizeLogs(tr.orig.InternalLogs)
+
+	tr.orig.SpanRecords = withoutNil(tr.orig.SpanRecords)
+	for _, span := range tr.orig.SpanRecords {
+		span.Attributes = withoutNil(span.Attributes)
+		span.JoinIds = withoutNil(span.JoinIds)
+		span.LogRecords = sanitizeLogs(span.LogRecords)
+	}
+}
+
+func withoutNil[T any](s []*T) []*T {
+	return slices.DeleteFunc(s, func(v *T) bool {
+		return v == nil
+	})
+}
+
+// runtimeScopeSpans returns the scope spans of the span runtime. Spans of runtimes other than the report's own
+// are put into separate ResourceSpans marked with the reporting runtime guid. The report resource describes the
+// forwarder, so these are built from the well-known reporter tags of the first span of the runtime instead
+func (tr *Request) runtimeScopeSpans(data ptrace.Traces, runtimes map[string]ptrace.ScopeSpans, span *collectorthrift.SpanRecord) ptrace.ScopeSpans {
+	guid := span.GetRuntimeGuid()
+	if ss, ok := runtimes[guid]; ok {
+		return ss
+	}
+
+	rs := data.ResourceSpans().AppendEmpty()
+	rs.SetSchemaUrl(data.ResourceSpans().At(0).SchemaUrl())
+
+	rAttr := rs.Resource().Attributes()
+	var nonUtf8Keys []string
+	for _, t := range span.GetAttributes() {
+		if key, ok := lightstepCommon.ResourceTagKey(t.GetKey()); ok {
+			tr.putStr(rAttr, key, t.GetValue(), &nonUtf8Keys)
+		}
+	}
+	if len(nonUtf8Keys) > 0 {
+		tr.reportNonUtf8(&nonUtf8Keys)
+		rs.Resource().SetDroppedAttributesCount(tr.config.NonUTF8.Dropped(&nonUtf8Keys))
+	}
+	if _, ok := rAttr.Get(lightstepCommon.ServiceNameAttribute); !ok {
+		rAttr.PutStr(lightstepCommon.ServiceNameAttribute, lightstepCommon.UnknownServiceName)
+	}
+	lightstepCommon.PutRuntimeIdentity(rAttr, guid, 0)
+	rAttr.PutStr(lightstepCommon.ReportingRuntimeAttribute, tr.orig.Runtime.GetGuid())
+
+	runtimes[guid] = rs.ScopeSpans().AppendEmpty()
+	return runtimes[guid]
+}
+
+// convertJoinIDs puts join ids into span attributes under the configured namespace, returning the keys with
+// invalid UTF8. Join ids of the configured link keys holding span contexts become span links as well
+func (tr *Request) convertJoinIDs(joinIDs []*collectorthrift.TraceJoinId, s ptrace.Span) []string {
+	var nonUtf8Keys []string
+	for _, j := range joinIDs {
+		if j.GetTraceKey() == "" {
+			continue
+		}
+		tr.putStr(s.Attributes(), tr.config.JoinIDs.Namespace+j.GetTraceKey(), j.GetValue(), &nonUtf8Keys)
+
+		if !slices.Contains(tr.config.JoinIDs.LinkKeys, j.GetTraceKey()) {
+			continue
+		}
+		traceID, spanID, ok := parseJoinSpanContext(j.GetValue())
+		if !ok {
+			tr.telemetry.Logger.Debug("can't convert join id into span link", zap.String("key", j.GetTraceKey()))
+			continue
+		}
+		link := s.Links().AppendEmpty()
+		link.SetTraceID(traceID)
+		link.SetSpanID(spanID)
+		link.Attributes().PutStr(lightstepCommon.JoinKeyAttribute, j.GetTraceKey())
+	}
+	return nonUtf8Keys
+}
+
+// parseJoinSpanContext parses span context join ids, W3C traceparent or hex encoded trace and span ids joined by colon
+func parseJoinSpanContext(v string) (pcommon.TraceID, pcommon.SpanID, bool) {
+	var traceHex, spanHex string
+	if parts := strings.Split(v, "-"); len(parts) == 4 {
+		traceHex, spanHex = parts[1], parts[2]
+	} else if t, sp, ok := strings.Cut(v, ":"); ok {
+		traceHex, spanHex = t, sp
+	}
+	if traceHex == "" || spanHex == "" || len(traceHex) > 32 || len(spanHex) > 16 {
+		return pcommon.NewTraceIDEmpty(), pcommon.NewSpanIDEmpty(), false
+	}
+
+	var traceID pcommon.TraceID
+	var spanID pcommon.SpanID
+	_, errTrace := hex.Decode(traceID[:], []byte(strings.Repeat("0", 32-len(traceHex))+traceHex))
+	_, errSpan := hex.Decode(spanID[:], []byte(strings.Repeat("0", 16-len(spanHex))+spanHex))
+	if errTrace != nil || errSpan != nil || traceID.IsEmpty() || spanID.IsEmpty() {
+		return pcommon.NewTraceIDEmpty(), pcommon.NewSpanIDEmpty(), false
+	}
+	return traceID, spanID, true
+}
+
+// spanLogRecords returns an empty list of report log records by guid of every span of the report
+func (tr *Request) spanLogRecords() map[string][]*collectorthrift.LogRecord {
+	res := make(map[string][]*collectorthrift.LogRecord, len(tr.orig.GetSpanRecords()))
+	for _, span := range tr.orig.GetSpanRecords() {
+		if span.IsSetSpanGuid() {
+			res[span.GetSpanGuid()] = nil
+		}
+	}
+	return res
+}
+
+// spanTraceIDs returns the trace id by guid of every span of the report
+func (tr *Request) spanTraceIDs() map[string]pcommon.TraceID {
+	res := make(map[string]pcommon.TraceID, len(tr.orig.GetSpanRecords()))
+	for _, span := range tr.orig.GetSpanRecords() {
+		if span.IsSetSpanGuid() {
+			res[span.GetSpanGuid()] = lightstepCommon.WithTraceIDUpper(tr.convertTraceID(span.GetTraceGuid()), tr.traceIDUpper(span.Attributes))
+		}
+	}
+	return res
+}
+
+// applyErrorFlags sets error status of spans flagged as errors by tracer itself or by any of its log records
+func (tr *Request) applyErrorFlags(flagged bool, logRecords []*collectorthrift.LogRecord, s ptrace.Span) {
+	message := ""
+	for _, log := range logRecords {
+		if !log.GetErrorFlag() {
+			continue
+		}
+		flagged = true
+		if message == "" {
+			message = log.GetMessage()
+		}
+		if message == "" {
+			message = log.GetStableName()
+		}
+	}
+	if !flagged {
+		return
+	}
+
+	if message == "" {
+		message = lightstepCommon.ExceptionMessage(s)
+	}
+	if message == "" {
+		message = errorFlagStatusMessage
+	}
+	s.Status().SetCode(ptrace.StatusCodeError)
+	lightstepCommon.SetDefaultStatusMessage(s, message)
+}
+
+// convertInternalMetrics converts tracer internal metrics and counters into Otel metrics
+func (tr *Request) convertInternalMetrics(resource pcommon.Map) pmetric.Metrics {
+	var start, end pcommon.Timestamp
+	if tr.orig.IsSetOldestMicros() {
+		start = pcommon.NewTimestampFromTime(time.UnixMicro(tr.orig.GetOldestMicros()))
+	}
+	if tr.orig.IsSetYoungestMicros() {
+		end = pcommon.NewTimestampFromTime(time.UnixMicro(tr.orig.GetYoungestMicros()))
+	} else {
+		end = pcommon.NewTimestampFromTime(time.Now())
+	}
+	tm := lightstepCommon.NewTracerMetrics(resource, start, end)
+
+	if im := tr.orig.GetInternalMetrics(); im != nil {
+		for _, m := range im.GetCounts() {
+			switch {
+			case m.IsSetInt64Value():
+				tm.AddCountInt(m.GetName(), m.GetInt64Value())
+			case m.IsSetDoubleValue():
+				tm.AddCountDouble(m.GetName(), m.GetDoubleValue())
+			}
+		}
+		for _, m := range im.GetGauges() {
+			switch {
+			case m.IsSetInt64Value():
+				tm.AddGaugeInt(m.GetName(), m.GetInt64Value())
+			case m.IsSetDoubleValue():
+				tm.AddGaugeDouble(m.GetName(), m.GetDoubleValue())
+			}
+		}
+	}
+
+	for _, c := range tr.orig.GetCounters() {
+		tm.AddCountInt(c.GetName(), c.GetValue())
+	}
+	return tm.Metrics()
+}
+
 func (tr *Request) convertTimestamp(v *int64) pcommon.Timestamp {
-	res := *v
-	return pcommon.NewTimestampFromTime(time.UnixMicro(res))
+	if v == nil {
+		return 0
+	}
+	return pcommon.NewTimestampFromTime(time.UnixMicro(*v))
 }
 
 func (tr *Request) convertSpanID(v string) pcommon.SpanID {
@@ -145,17 +460,33 @@ func (tr *Request) convertSpanID(v string) pcommon.SpanID {
 }
 
 func (tr *Request) convertTraceID(v string) pcommon.TraceID {
-	if len(v) < 16 {
-		v = strings.Repeat("0", 16-len(v)) + v
+	if len(v) > 32 {
+		tr.telemetry.Logger.Warn("can't convert trace id", zap.String("trace id", v))
+		return pcommon.NewTraceIDEmpty()
 	}
+	v = strings.Repeat("0", 32-len(v)) + v
 
-	b1 := make([]byte, 8)
-	b2, err := hex.DecodeString(v)
+	b, err := hex.DecodeString(v)
 	if err != nil {
-		tr.telemetry.Logger.Warn("can't convert trace id", zap.String("span id", v), zap.Error(err))
+		tr.telemetry.Logger.Warn("can't convert trace id", zap.String("trace id", v), zap.Error(err))
 		return pcommon.NewTraceIDEmpty()
 	}
+	return pcommon.TraceID(b)
+}
 
-	b1 = append(b1, b2...)
-	return pcommon.TraceID(b1)
+// traceIDUpper looks up the upper half of the trace id in the configured span tag
+func (tr *Request) traceIDUpper(kv []*collectorthrift.KeyValue) uint64 {
+	if tr.config.TraceID.UpperTag == "" {
+		return 0
+	}
+	for _, t := range kv {
+		if t.GetKey() != tr.config.TraceID.UpperTag {
+			continue
+		}
+		if upper, ok := lightstepCommon.ParseTraceIDUpper(t.GetValue()); ok {
+			return upper
+		}
+		tr.telemetry.Logger.Debug("can't parse upper trace id", zap.String("key", t.GetKey()))
+	}
+	return 0
 }
@@ -1,10 +1,24 @@
 package lightstep_thrift
 
 import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/matryer/is"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/receiver"
-	"testing"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
 
+	lightstepCommon "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_common"
+	lightstepPb "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_pb"
+	pb "github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_pb/collectorpb"
+	"github.com/zalando/otelcol-lightstep-receiver/internal/lightstep_thrift/collectorthrift"
 	"github.com/zalando/otelcol-lightstep-receiver/internal/telemetry"
 )
 
@@ -15,6 +29,23 @@ func initTr() *Request {
 	return res
 }
 
+func initRequest(orig *collectorthrift.ReportRequest, config *lightstepCommon.TransformConfig) *Request {
+	t := &telemetry.Telemetry{}
+	logger, _ := zap.NewDevelopment(zap.WithCaller(true))
+	t.Init(receiver.Settings{
+		TelemetrySettings: component.TelemetrySettings{
+			TracerProvider: noop.NewTracerProvider(),
+			Logger:         logger,
+		},
+	})
+	accessToken := "access-token"
+	return NewThriftRequest(&collectorthrift.Auth{AccessToken: &accessToken}, orig, t, config)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func TestConvertSpanID(t *testing.T) {
 	is := is.New(t)
 	tr := initTr()
@@ -43,6 +74,19 @@ func TestConvertTraceID_Odd(t *testing.T) {
 	is.Equal(val.String(), "0000000000000000000994087c3bf8be")
 }
 
+func TestConvertTraceID_128Bit(t *testing.T) {
+	is := is.New(t)
+	tr := initRequest(nil, nil)
+	val := tr.convertTraceID("4bf92f3577b34da6a3ce929d0e0e4736")
+	is.Equal(val.String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+
+	val = tr.convertTraceID("bf92f3577b34da6a3ce929d0e0e4736")
+	is.Equal(val.String(), "0bf92f3577b34da6a3ce929d0e0e4736")
+
+	val = tr.convertTraceID("14bf92f3577b34da6a3ce929d0e0e4736")
+	is.True(val.IsEmpty())
+}
+
 func TestConvertTimestamp(t *testing.T) {
 	is := is.New(t)
 	tr := initTr()
@@ -51,3 +95,1319 @@ func TestConvertTimestamp(t *testing.T) {
 	val := tr.convertTimestamp(&v)
 	is.Equal(val.String(), "2024-07-27 10:12:08.424658 +0000 UTC")
 }
+
+func TestTransformation_ClockCorrection(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("1c5994087c3bf8be"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				LogRecords: []*collectorthrift.LogRecord{
+					{TimestampMicros: ptr(int64(1722075128500000))},
+				},
+			},
+		},
+		LogRecords: []*collectorthrift.LogRecord{
+			{TimestampMicros: ptr(int64(1722075128500000))},
+		},
+		TimestampOffsetMicros: ptr(int64(5000000)),
+	}
+
+	res, err := initRequest(orig, nil).ToOtel(context.Background())
+	is.NoErr(err)
+	span := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(span.StartTimestamp().AsTime().UnixMicro(), int64(1722075128000000))
+	_, ok := span.Attributes().Get(lightstepCommon.ClockCorrectionAttribute)
+	is.True(!ok)
+
+	res, err = initRequest(orig, &lightstepCommon.TransformConfig{
+		ClockCorrection: lightstepCommon.ClockCorrectionConfig{Enabled: true, MaxOffset: 2 * time.Second},
+		Logs:            lightstepCommon.LogsConfig{SpanEvents: true},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	span = res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(span.StartTimestamp().AsTime().UnixMicro(), int64(1722075130000000))
+	is.Equal(span.EndTimestamp().AsTime().UnixMicro(), int64(1722075131000000))
+	is.Equal(span.Events().At(0).Timestamp().AsTime().UnixMicro(), int64(1722075130500000))
+	v, ok := span.Attributes().Get(lightstepCommon.ClockCorrectionAttribute)
+	is.True(ok)
+	is.Equal(v.Int(), int64(2000000))
+
+	records := res.Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	is.Equal(records.Len(), 2)
+	for i := 0; i < records.Len(); i++ {
+		is.Equal(records.At(i).Timestamp().AsTime().UnixMicro(), int64(1722075130500000))
+	}
+	v, ok = records.At(0).Attributes().Get(lightstepCommon.ClockCorrectionAttribute)
+	is.True(ok)
+	is.Equal(v.Int(), int64(2000000))
+}
+
+func TestTransformation_TraceIDUpperTag(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				Attributes: []*collectorthrift.KeyValue{
+					{Key: "lightstep.trace_id_upper", Value: "4bf92f3577b34da6"},
+				},
+			},
+		},
+	}
+
+	res, err := initRequest(orig, &lightstepCommon.TransformConfig{
+		TraceID: lightstepCommon.TraceIDConfig{UpperTag: "lightstep.trace_id_upper"},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	span := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(span.TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	is.Equal(span.Attributes().Len(), 0)
+
+	res, err = initRequest(orig, &lightstepCommon.TransformConfig{
+		TraceID:       lightstepCommon.TraceIDConfig{UpperTag: "lightstep.trace_id_upper"},
+		LightstepTags: lightstepCommon.LightstepTagsConfig{Mode: lightstepCommon.LightstepTagsModeAll, Prefix: "legacy.lightstep."},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	span = res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(span.TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	is.Equal(span.Attributes().Len(), 0)
+}
+
+func TestTransformation_SemConv(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				Attributes: []*collectorthrift.KeyValue{
+					{Key: "http.url", Value: "http://example.com"},
+					{Key: "db.instance", Value: "orders"},
+					{Key: "custom.tag", Value: "custom"},
+					{Key: "peer.hostname", Value: "peer"},
+					{Key: "server.address", Value: "server"},
+				},
+			},
+		},
+	}
+
+	res, err := initRequest(orig, &lightstepCommon.TransformConfig{
+		SemConv: lightstepCommon.SemConvConfig{
+			Version: "1.26.0",
+			Renames: map[string]string{"custom.tag": "app.custom"},
+		},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(res.ResourceSpans().At(0).SchemaUrl(), "https://opentelemetry.io/schemas/1.26.0")
+
+	attr := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+	for key, expected := range map[string]string{
+		"url.full":       "http://example.com",
+		"db.namespace":   "orders",
+		"app.custom":     "custom",
+		"server.address": "server",
+		"peer.hostname":  "peer",
+	} {
+		v, ok := attr.Get(key)
+		is.True(ok)
+		is.Equal(v.Str(), expected)
+	}
+	_, ok := attr.Get("http.url")
+	is.True(!ok)
+}
+
+func TestTransformation_InternalMetrics(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{
+			Attrs: []*collectorthrift.KeyValue{
+				{Key: "lightstep.component_name", Value: "service.name"},
+			},
+		},
+		OldestMicros:   ptr(int64(1722075128000000)),
+		YoungestMicros: ptr(int64(1722075129000000)),
+		InternalMetrics: &collectorthrift.Metrics{
+			Counts: []*collectorthrift.MetricsSample{
+				{Name: "spans.dropped", Int64Value: ptr(int64(3))},
+			},
+			Gauges: []*collectorthrift.MetricsSample{
+				{Name: "buffer.usage", DoubleValue: ptr(0.5)},
+			},
+		},
+		Counters: []*collectorthrift.NamedCounter{
+			{Name: "reports.sent", Value: 7},
+		},
+	}
+
+	res, err := initRequest(orig, nil).ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(res.ClientSpansDropped, int64(3))
+	is.Equal(res.Metrics.DataPointCount(), 3)
+
+	rm := res.Metrics.ResourceMetrics().At(0)
+	v, ok := rm.Resource().Attributes().Get("service.name")
+	is.True(ok)
+	is.Equal(v.Str(), "service.name")
+
+	metrics := rm.ScopeMetrics().At(0).Metrics()
+	is.Equal(metrics.At(0).Name(), "lightstep.tracer.spans.dropped")
+	is.Equal(metrics.At(0).Sum().DataPoints().At(0).IntValue(), int64(3))
+	is.Equal(metrics.At(1).Name(), "lightstep.tracer.buffer.usage")
+	is.Equal(metrics.At(1).Gauge().DataPoints().At(0).DoubleValue(), 0.5)
+	is.Equal(metrics.At(2).Name(), "lightstep.tracer.reports.sent")
+	is.Equal(metrics.At(2).Sum().DataPoints().At(0).IntValue(), int64(7))
+	is.Equal(metrics.At(2).Sum().DataPoints().At(0).Timestamp().AsTime().UnixMicro(), int64(1722075129000000))
+}
+
+func TestTransformation_Logs(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{
+			Attrs: []*collectorthrift.KeyValue{
+				{Key: "lightstep.component_name", Value: "service.name"},
+			},
+		},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				LogRecords: []*collectorthrift.LogRecord{
+					{
+						TimestampMicros: ptr(int64(1722075128500000)),
+						Fields: []*collectorthrift.KeyValue{
+							{Key: "event", Value: "cache-miss"},
+						},
+					},
+				},
+			},
+		},
+		LogRecords: []*collectorthrift.LogRecord{
+			{
+				TimestampMicros: ptr(int64(1722075128000000)),
+				StableName:      ptr("page-load"),
+				Message:         ptr("page loaded"),
+				Level:           ptr("W"),
+				Filename:        ptr("app.js"),
+				LineNumber:      ptr(int64(42)),
+				StackFrames:     []string{"frame1", "frame2"},
+				ThreadId:        ptr(int64(7)),
+				PayloadJson:     ptr(`{"k":"v"}`),
+				Fields: []*collectorthrift.KeyValue{
+					{Key: "field", Value: "value"},
+				},
+			},
+		},
+		InternalLogs: []*collectorthrift.LogRecord{
+			{
+				Message:   ptr("buffer full"),
+				ErrorFlag: ptr(true),
+			},
+		},
+	}
+
+	res, err := initRequest(orig, &lightstepCommon.TransformConfig{
+		Logs: lightstepCommon.LogsConfig{SpanEvents: true},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(res.Logs.LogRecordCount(), 3)
+
+	rl := res.Logs.ResourceLogs().At(0)
+	v, ok := rl.Resource().Attributes().Get("service.name")
+	is.True(ok)
+	is.Equal(v.Str(), "service.name")
+
+	records := rl.ScopeLogs().At(0).LogRecords()
+	lr := records.At(0)
+	is.Equal(lr.Body().Str(), "page loaded")
+	is.Equal(lr.EventName(), "page-load")
+	is.Equal(lr.SeverityText(), "W")
+	is.Equal(lr.SeverityNumber(), plog.SeverityNumberWarn)
+	is.Equal(lr.Timestamp().AsTime().UnixMicro(), int64(1722075128000000))
+	for key, expected := range map[string]any{
+		"code.filepath":        "app.js",
+		"code.lineno":          int64(42),
+		"exception.stacktrace": "frame1\nframe2",
+		"thread.id":            int64(7),
+		"payload":              `{"k":"v"}`,
+		"field":                "value",
+	} {
+		v, ok = lr.Attributes().Get(key)
+		is.True(ok)
+		is.Equal(v.AsRaw(), expected)
+	}
+
+	lr = records.At(1)
+	is.Equal(lr.Body().Str(), "buffer full")
+	is.Equal(lr.SeverityNumber(), plog.SeverityNumberError)
+	v, ok = lr.Attributes().Get(lightstepCommon.InternalLogAttribute)
+	is.True(ok)
+	is.Equal(v.Bool(), true)
+
+	lr = records.At(2)
+	is.Equal(lr.EventName(), "cache-miss")
+	is.Equal(lr.TraceID().String(), "0000000000000000a3ce929d0e0e4736")
+	is.Equal(lr.SpanID().String(), "1c5994087c3bf8be")
+	is.Equal(lr.Timestamp().AsTime().UnixMicro(), int64(1722075128500000))
+}
+
+func TestTransformation_SpanLogRecordFields(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				LogRecords: []*collectorthrift.LogRecord{
+					{
+						TimestampMicros: ptr(int64(1722075128500000)),
+						StableName:      ptr("cache-miss"),
+						Message:         ptr("key not found"),
+						Level:           ptr("I"),
+						Filename:        ptr("cache.py"),
+						LineNumber:      ptr(int64(10)),
+					},
+					{
+						TimestampMicros: ptr(int64(1722075128600000)),
+						Message:         ptr("retrying"),
+					},
+					{
+						TimestampMicros: ptr(int64(1722075128700000)),
+						StableName:      ptr("ValueError"),
+						Message:         ptr("invalid literal"),
+						StackFrames:     []string{"main.py:1", "lib.py:2"},
+						ErrorFlag:       ptr(true),
+					},
+				},
+			},
+		},
+	}
+
+	res, err := initRequest(orig, nil).ToOtel(context.Background())
+	is.NoErr(err)
+	events := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Events()
+	is.Equal(events.Len(), 3)
+
+	ev := events.At(0)
+	is.Equal(ev.Name(), "cache-miss")
+	for key, expected := range map[string]any{
+		"message":       "key not found",
+		"level":         "I",
+		"code.filepath": "cache.py",
+		"code.lineno":   int64(10),
+	} {
+		v, ok := ev.Attributes().Get(key)
+		is.True(ok)
+		is.Equal(v.AsRaw(), expected)
+	}
+
+	ev = events.At(1)
+	is.Equal(ev.Name(), "retrying")
+	is.Equal(ev.Attributes().Len(), 0)
+
+	ev = events.At(2)
+	is.Equal(ev.Name(), "exception")
+	for key, expected := range map[string]any{
+		"exception.type":       "ValueError",
+		"exception.message":    "invalid literal",
+		"exception.stacktrace": "main.py:1\nlib.py:2",
+	} {
+		v, ok := ev.Attributes().Get(key)
+		is.True(ok)
+		is.Equal(v.AsRaw(), expected)
+	}
+	_, ok := ev.Attributes().Get("message")
+	is.True(!ok)
+}
+
+func TestTransformation_NonUTF8Fields(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				SpanName:       ptr("op\xff"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				JoinIds: []*collectorthrift.TraceJoinId{
+					{TraceKey: "end_user_id", Value: "user\xff"},
+				},
+				LogRecords: []*collectorthrift.LogRecord{
+					{
+						TimestampMicros: ptr(int64(1722075128500000)),
+						StableName:      ptr("cache-miss"),
+						Message:         ptr("key\xc3\x28"),
+						Level:           ptr("l\xff"),
+						Filename:        ptr("cache\xff.py"),
+						StackFrames:     []string{"main.py:1", "lib\xff.py:2"},
+						PayloadJson:     ptr("{\"key\": \"\xff\"}"),
+					},
+				},
+			},
+		},
+	}
+
+	tr := initRequest(orig, &lightstepCommon.TransformConfig{
+		JoinIDs: lightstepCommon.JoinIDsConfig{Namespace: "join_id."},
+	})
+	res, err := tr.ToOtel(context.Background())
+	is.NoErr(err)
+	span := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+
+	is.Equal(span.Name(), "op\uFFFD")
+	_, ok := span.Attributes().Get("join_id.end_user_id")
+	is.True(!ok)
+	is.Equal(span.DroppedAttributesCount(), uint32(1))
+
+	ev := span.Events().At(0)
+	is.Equal(ev.Name(), "cache-miss")
+	for _, key := range []string{"message", "level", "code.filepath", "exception.stacktrace", "payload"} {
+		_, ok := ev.Attributes().Get(key)
+		is.True(!ok)
+	}
+	is.Equal(ev.DroppedAttributesCount(), uint32(5))
+	is.Equal(tr.telemetry.NonUTF8Attributes[transport], int64(7))
+}
+
+func TestTransformation_NonUTF8LogFields(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				LogRecords: []*collectorthrift.LogRecord{
+					{Message: ptr("bad\xff"), ErrorFlag: ptr(true)},
+				},
+			},
+		},
+		LogRecords: []*collectorthrift.LogRecord{
+			{Message: ptr("bad\xff"), Level: ptr("l\xff")},
+		},
+	}
+
+	res, err := initRequest(orig, &lightstepCommon.TransformConfig{
+		NonUTF8: lightstepCommon.NonUTF8Config{Mode: lightstepCommon.NonUTF8ModeBytes},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+
+	ev := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Events().At(0)
+	is.Equal(ev.Name(), "exception")
+	v, ok := ev.Attributes().Get("exception.message")
+	is.True(ok)
+	is.Equal(v.Bytes().AsRaw(), []byte("bad\xff"))
+	is.Equal(ev.DroppedAttributesCount(), uint32(0))
+
+	lr := res.Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	is.Equal(lr.Body().Str(), "bad\uFFFD")
+	is.Equal(lr.SeverityText(), "l\uFFFD")
+}
+
+func TestTransformation_NonUTF8Key(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				Attributes: []*collectorthrift.KeyValue{
+					{Key: "key\xff", Value: "valid"},
+					{Key: "value", Value: "pok\xe9mon"},
+				},
+			},
+		},
+	}
+
+	res, err := initRequest(orig, &lightstepCommon.TransformConfig{
+		NonUTF8: lightstepCommon.NonUTF8Config{Mode: lightstepCommon.NonUTF8ModeBase64, Base64Suffix: ";base64"},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	attr := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes()
+
+	v, ok := attr.Get("key\uFFFD")
+	is.True(ok)
+	is.Equal(v.Str(), "valid")
+	v, ok = attr.Get("value")
+	is.True(ok)
+	is.Equal(v.Str(), "cG9r6W1vbg==;base64")
+}
+
+func TestTransformation_ErrorFlag(t *testing.T) {
+	is := is.New(t)
+	span := func(guid string) *collectorthrift.SpanRecord {
+		return &collectorthrift.SpanRecord{
+			SpanGuid:       ptr(guid),
+			TraceGuid:      ptr("a3ce929d0e0e4736"),
+			OldestMicros:   ptr(int64(1722075128000000)),
+			YoungestMicros: ptr(int64(1722075129000000)),
+		}
+	}
+
+	errorTag := span("0000000000000001")
+	errorTag.Attributes = []*collectorthrift.KeyValue{{Key: "error", Value: "true"}}
+
+	spanFlag := span("0000000000000002")
+	spanFlag.ErrorFlag = ptr(true)
+
+	logFlag := span("0000000000000003")
+	logFlag.LogRecords = []*collectorthrift.LogRecord{
+		{Message: ptr("first")},
+		{Message: ptr("connection reset"), ErrorFlag: ptr(true)},
+	}
+
+	noError := span("0000000000000004")
+	noError.ErrorFlag = ptr(false)
+	noError.Attributes = []*collectorthrift.KeyValue{{Key: "error", Value: "false"}}
+
+	orig := &collectorthrift.ReportRequest{
+		Runtime:     &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{errorTag, spanFlag, logFlag, noError},
+	}
+
+	res, err := initRequest(orig, nil).ToOtel(context.Background())
+	is.NoErr(err)
+	spans := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+
+	is.Equal(spans.At(0).Status().Code(), ptrace.StatusCodeError)
+	is.Equal(spans.At(0).Status().Message(), "")
+	_, ok := spans.At(0).Attributes().Get("error")
+	is.True(!ok)
+
+	is.Equal(spans.At(1).Status().Code(), ptrace.StatusCodeError)
+	is.Equal(spans.At(1).Status().Message(), "error flag set by tracer")
+
+	is.Equal(spans.At(2).Status().Code(), ptrace.StatusCodeError)
+	is.Equal(spans.At(2).Status().Message(), "connection reset")
+
+	is.Equal(spans.At(3).Status().Code(), ptrace.StatusCodeUnset)
+	v, ok := spans.At(3).Attributes().Get("error")
+	is.True(ok)
+	is.Equal(v.Str(), "false")
+}
+
+func TestTransformation_ErrorTagParity(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		tags    map[string]string
+		fields  map[string]string
+		code    ptrace.StatusCode
+		message string
+	}{
+		{
+			name: "error tag",
+			tags: map[string]string{"error": "true"},
+			code: ptrace.StatusCodeError,
+		},
+		{
+			name:    "error tag with message tag",
+			tags:    map[string]string{"error": "true", "error.message": "timeout"},
+			code:    ptrace.StatusCodeError,
+			message: "timeout",
+		},
+		{
+			name:    "error tag with error log",
+			tags:    map[string]string{"error": "true"},
+			fields:  map[string]string{"event": "error", "message": "connection reset"},
+			code:    ptrace.StatusCodeError,
+			message: "connection reset",
+		},
+		{
+			name: "no error",
+			tags: map[string]string{"error": "false"},
+			code: ptrace.StatusCodeUnset,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			config := &lightstepCommon.TransformConfig{Status: lightstepCommon.StatusConfig{MessageTags: []string{"error.message"}}}
+			thriftSpan, pbSpan := transformBoth(is, config, tc.tags, tc.fields)
+
+			is.Equal(thriftSpan.Status().Code(), tc.code)
+			is.Equal(thriftSpan.Status().Message(), tc.message)
+			is.Equal(pbSpan.Status().Code(), tc.code)
+			is.Equal(pbSpan.Status().Message(), tc.message)
+			is.Equal(thriftSpan.Attributes().AsRaw(), pbSpan.Attributes().AsRaw())
+		})
+	}
+}
+
+func TestTransformation_ServiceOverride(t *testing.T) {
+	is := is.New(t)
+	span := func(guid string, attrs ...*collectorthrift.KeyValue) *collectorthrift.SpanRecord {
+		return &collectorthrift.SpanRecord{
+			SpanGuid:       ptr(guid),
+			TraceGuid:      ptr("a3ce929d0e0e4736"),
+			OldestMicros:   ptr(int64(1722075128000000)),
+			YoungestMicros: ptr(int64(1722075129000000)),
+			Attributes:     attrs,
+		}
+	}
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{
+			Attrs: []*collectorthrift.KeyValue{
+				{Key: "lightstep.component_name", Value: "gateway"},
+				{Key: "lightstep.hostname", Value: "host-1"},
+			},
+		},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			span("0000000000000001"),
+			span("0000000000000002", &collectorthrift.KeyValue{Key: "peer.service", Value: "orders"}),
+			span("0000000000000003", &collectorthrift.KeyValue{Key: "service", Value: "gateway"}),
+			span("0000000000000004",
+				&collectorthrift.KeyValue{Key: "service", Value: "payments"},
+				&collectorthrift.KeyValue{Key: "peer.service", Value: "orders"},
+			),
+		},
+	}
+
+	res, err := initRequest(orig, &lightstepCommon.TransformConfig{
+		ServiceOverride: lightstepCommon.ServiceOverrideConfig{Tags: []string{"service", "peer.service"}, StripTag: true},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(res.ResourceSpans().Len(), 3)
+
+	for i, expected := range []struct {
+		service string
+		spans   []string
+	}{
+		{service: "gateway", spans: []string{"0000000000000001", "0000000000000003"}},
+		{service: "orders", spans: []string{"0000000000000002"}},
+		{service: "payments", spans: []string{"0000000000000004"}},
+	} {
+		rs := res.ResourceSpans().At(i)
+		v, ok := rs.Resource().Attributes().Get("service.name")
+		is.True(ok)
+		is.Equal(v.Str(), expected.service)
+		v, ok = rs.Resource().Attributes().Get("host.name")
+		is.True(ok)
+		is.Equal(v.Str(), "host-1")
+
+		spans := rs.ScopeSpans().At(0).Spans()
+		is.Equal(spans.Len(), len(expected.spans))
+		for j, id := range expected.spans {
+			is.Equal(spans.At(j).SpanID().String(), id)
+			_, ok = spans.At(j).Attributes().Get("service")
+			is.True(!ok)
+		}
+	}
+
+	attr := res.ResourceSpans().At(1).ScopeSpans().At(0).Spans().At(0).Attributes()
+	_, ok := attr.Get("peer.service")
+	is.True(!ok)
+	attr = res.ResourceSpans().At(2).ScopeSpans().At(0).Spans().At(0).Attributes()
+	v, ok := attr.Get("peer.service")
+	is.True(ok)
+	is.Equal(v.Str(), "orders")
+}
+
+func TestTransformation_ServiceOverrideAllSpans(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{
+			Attrs: []*collectorthrift.KeyValue{{Key: "lightstep.component_name", Value: "gateway"}},
+		},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("0000000000000001"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				Attributes:     []*collectorthrift.KeyValue{{Key: "service", Value: "orders"}},
+			},
+		},
+	}
+
+	res, err := initRequest(orig, &lightstepCommon.TransformConfig{
+		ServiceOverride: lightstepCommon.ServiceOverrideConfig{Tags: []string{"service"}},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(res.ResourceSpans().Len(), 1)
+
+	rs := res.ResourceSpans().At(0)
+	v, ok := rs.Resource().Attributes().Get("service.name")
+	is.True(ok)
+	is.Equal(v.Str(), "orders")
+	is.Equal(rs.ScopeSpans().Len(), 1)
+	is.Equal(rs.ScopeSpans().At(0).Spans().At(0).SpanID().String(), "0000000000000001")
+}
+
+func TestTransformation_JoinIDs(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				JoinIds: []*collectorthrift.TraceJoinId{
+					{TraceKey: "end_user_id", Value: "user-1"},
+					{TraceKey: "correlated_span", Value: "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7"},
+					{TraceKey: "traceparent", Value: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+					{TraceKey: "correlated_trace", Value: "4bf92f3577b34da6a3ce929d0e0e4736"},
+					{TraceKey: "correlated_invalid", Value: "not-a-trace-id:00f067aa0ba902b7"},
+				},
+			},
+		},
+	}
+
+	res, err := initRequest(orig, &lightstepCommon.TransformConfig{
+		JoinIDs: lightstepCommon.JoinIDsConfig{
+			Namespace: "join_id.",
+			LinkKeys:  []string{"correlated_span", "traceparent", "correlated_trace", "correlated_invalid"},
+		},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	span := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+
+	for key, expected := range map[string]string{
+		"join_id.end_user_id":        "user-1",
+		"join_id.correlated_span":    "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7",
+		"join_id.traceparent":        "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"join_id.correlated_trace":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"join_id.correlated_invalid": "not-a-trace-id:00f067aa0ba902b7",
+	} {
+		v, ok := span.Attributes().Get(key)
+		is.True(ok)
+		is.Equal(v.Str(), expected)
+	}
+
+	is.Equal(span.Links().Len(), 2)
+	for i, expected := range []struct {
+		key, traceID, spanID string
+	}{
+		{key: "correlated_span", traceID: "4bf92f3577b34da6a3ce929d0e0e4736", spanID: "00f067aa0ba902b7"},
+		{key: "traceparent", traceID: "0af7651916cd43dd8448eb211c80319c", spanID: "b7ad6b7169203331"},
+	} {
+		link := span.Links().At(i)
+		is.Equal(link.TraceID().String(), expected.traceID)
+		is.Equal(link.SpanID().String(), expected.spanID)
+		v, ok := link.Attributes().Get(lightstepCommon.JoinKeyAttribute)
+		is.True(ok)
+		is.Equal(v.Str(), expected.key)
+	}
+}
+
+func TestTransformation_TypeCoercion(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+				Attributes: []*collectorthrift.KeyValue{
+					{Key: "parent_span_guid", Value: "1234567890123456"},
+					{Key: "http.status_code", Value: "500"},
+					{Key: "ratio", Value: "0.25"},
+					{Key: "cached", Value: "false"},
+					{Key: "zip", Value: "01234"},
+					{Key: "version", Value: "1.2.3"},
+					{Key: "exp", Value: "1e5"},
+					{Key: "customer.id", Value: "42"},
+					{Key: "retries", Value: "3.0"},
+				},
+				JoinIds: []*collectorthrift.TraceJoinId{
+					{TraceKey: "end_user_id", Value: "12345"},
+				},
+				LogRecords: []*collectorthrift.LogRecord{
+					{
+						TimestampMicros: ptr(int64(1722075128500000)),
+						Fields: []*collectorthrift.KeyValue{
+							{Key: "customer.id", Value: "42"},
+							{Key: "retries", Value: "3.0"},
+						},
+					},
+				},
+			},
+		},
+		InternalLogs: []*collectorthrift.LogRecord{
+			{
+				Message: ptr("flush failed"),
+				Fields: []*collectorthrift.KeyValue{
+					{Key: "customer.id", Value: "42"},
+					{Key: "retries", Value: "3.0"},
+				},
+			},
+		},
+	}
+
+	res, err := initRequest(orig, &lightstepCommon.TransformConfig{
+		TypeCoercion: lightstepCommon.TypeCoercionConfig{
+			Infer: true,
+			Schema: map[string]lightstepCommon.AttributeType{
+				"customer.id": lightstepCommon.AttributeTypeString,
+				"retries":     lightstepCommon.AttributeTypeInt,
+			},
+		},
+		JoinIDs: lightstepCommon.JoinIDsConfig{Namespace: "join_id."},
+	}).ToOtel(context.Background())
+	is.NoErr(err)
+	span := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(span.ParentSpanID().String(), "1234567890123456")
+
+	for key, expected := range map[string]any{
+		"http.status_code":    int64(500),
+		"ratio":               0.25,
+		"cached":              false,
+		"zip":                 "01234",
+		"version":             "1.2.3",
+		"exp":                 "1e5",
+		"customer.id":         "42",
+		"retries":             int64(3),
+		"join_id.end_user_id": "12345",
+	} {
+		v, ok := span.Attributes().Get(key)
+		is.True(ok)
+		is.Equal(v.AsRaw(), expected)
+	}
+
+	lr := res.Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	for _, attr := range []pcommon.Map{span.Events().At(0).Attributes(), lr.Attributes()} {
+		v, ok := attr.Get("customer.id")
+		is.True(ok)
+		is.Equal(v.AsRaw(), "42")
+		v, ok = attr.Get("retries")
+		is.True(ok)
+		is.Equal(v.AsRaw(), int64(3))
+	}
+}
+
+func TestTransformation_RuntimeAttrs(t *testing.T) {
+	is := is.New(t)
+	tr := initRequest(&collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{
+			Attrs: []*collectorthrift.KeyValue{
+				{Key: "lightstep.component_name", Value: "svc"},
+				{Key: "lightstep.hostname", Value: "host-1"},
+				{Key: "lightstep.guid", Value: "5a2f0c"},
+				{Key: "lightstep.tracer_platform", Value: "jre"},
+				{Key: "lightstep.tracer_platform_version", Value: "11"},
+				{Key: "lightstep.tracer_version", Value: "0.14.8"},
+				{Key: "host.name", Value: "explicit"},
+			},
+		},
+	}, nil)
+	rqOtel, err := tr.ToOtel(context.Background())
+	is.NoErr(err)
+
+	rAttr := rqOtel.ResourceSpans().At(0).Resource().Attributes()
+	for key, expected := range map[string]string{
+		"host.name":               "explicit",
+		"service.instance.id":     "5a2f0c",
+		"telemetry.sdk.language":  "jre",
+		"process.runtime.version": "11",
+		"telemetry.sdk.version":   "0.14.8",
+	} {
+		v, ok := rAttr.Get(key)
+		is.True(ok)
+		is.Equal(v.Str(), expected)
+	}
+}
+
+func TestTransformation_RuntimeIdentity(t *testing.T) {
+	is := is.New(t)
+	tr := initRequest(&collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{
+			Guid:        ptr("5a2f0c"),
+			StartMicros: ptr(int64(1718207928000123)),
+			GroupName:   ptr("checkout"),
+			Attrs: []*collectorthrift.KeyValue{
+				{Key: "lightstep.component_name", Value: "svc"},
+			},
+		},
+	}, &lightstepCommon.TransformConfig{
+		Runtime: lightstepCommon.RuntimeConfig{GroupNameAttribute: "lightstep.group_name"},
+	})
+	rqOtel, err := tr.ToOtel(context.Background())
+	is.NoErr(err)
+
+	rAttr := rqOtel.ResourceSpans().At(0).Resource().Attributes()
+	for key, expected := range map[string]string{
+		"service.instance.id":   "5a2f0c",
+		"process.creation.time": "2024-06-12T15:58:48.000123Z",
+		"lightstep.group_name":  "checkout",
+	} {
+		v, ok := rAttr.Get(key)
+		is.True(ok)
+		is.Equal(v.Str(), expected)
+	}
+}
+
+func TestTransformation_RuntimeGuids(t *testing.T) {
+	is := is.New(t)
+	spanRecord := func(spanGuid string, runtimeGuid *string, attrs ...*collectorthrift.KeyValue) *collectorthrift.SpanRecord {
+		return &collectorthrift.SpanRecord{
+			SpanGuid:       ptr(spanGuid),
+			TraceGuid:      ptr("1c5994087c3bf8be"),
+			RuntimeGuid:    runtimeGuid,
+			OldestMicros:   ptr(int64(1000)),
+			YoungestMicros: ptr(int64(2000)),
+			Attributes:     attrs,
+		}
+	}
+	tr := initRequest(&collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{
+			Guid:        ptr("own"),
+			StartMicros: ptr(int64(1718207928000000)),
+			Attrs: []*collectorthrift.KeyValue{
+				{Key: "lightstep.component_name", Value: "svc"},
+				{Key: "lightstep.hostname", Value: "forwarder"},
+				{Key: "lightstep.tracer_platform", Value: "python"},
+			},
+		},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			spanRecord("01", ptr("own")),
+			spanRecord("02", ptr("other"),
+				&collectorthrift.KeyValue{Key: "lightstep.component_name", Value: "other-svc"},
+				&collectorthrift.KeyValue{Key: "lightstep.hostname", Value: "other-host"},
+			),
+			spanRecord("03", nil),
+			spanRecord("04", ptr("other")),
+			spanRecord("05", ptr("anonymous")),
+		},
+	}, nil)
+	rqOtel, err := tr.ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(rqOtel.ResourceSpans().Len(), 3)
+
+	own := rqOtel.ResourceSpans().At(0)
+	is.Equal(own.ScopeSpans().At(0).Spans().Len(), 2)
+	_, ok := own.Resource().Attributes().Get(lightstepCommon.ReportingRuntimeAttribute)
+	is.True(!ok)
+
+	other := rqOtel.ResourceSpans().At(1)
+	is.Equal(other.ScopeSpans().At(0).Spans().Len(), 2)
+	is.Equal(other.ScopeSpans().At(0).Spans().At(0).SpanID().String(), "0000000000000002")
+	rAttr := other.Resource().Attributes()
+	for key, expected := range map[string]string{
+		"service.name":        "other-svc",
+		"host.name":           "other-host",
+		"service.instance.id": "other",
+		lightstepCommon.ReportingRuntimeAttribute: "own",
+	} {
+		v, ok := rAttr.Get(key)
+		is.True(ok)
+		is.Equal(v.Str(), expected)
+	}
+	for _, key := range []string{"process.creation.time", "telemetry.sdk.language"} {
+		_, ok = rAttr.Get(key)
+		is.True(!ok)
+	}
+
+	v, ok := rqOtel.ResourceSpans().At(2).Resource().Attributes().Get("service.name")
+	is.True(ok)
+	is.Equal(v.Str(), lightstepCommon.UnknownServiceName)
+}
+
+func TestTransformation_ReportLogRecordsBySpanGuid(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				OldestMicros:   ptr(int64(1722075128000000)),
+				YoungestMicros: ptr(int64(1722075129000000)),
+			},
+		},
+		LogRecords: []*collectorthrift.LogRecord{
+			{
+				TimestampMicros: ptr(int64(1722075128500000)),
+				SpanGuid:        ptr("1c5994087c3bf8be"),
+				StableName:      ptr("request-failed"),
+				ErrorFlag:       ptr(true),
+			},
+			{
+				TimestampMicros: ptr(int64(1722075128600000)),
+				SpanGuid:        ptr("00000000000000aa"),
+				Message:         ptr("flushed earlier"),
+			},
+			{
+				TimestampMicros: ptr(int64(1722075128700000)),
+				Message:         ptr("page loaded"),
+			},
+		},
+		InternalLogs: []*collectorthrift.LogRecord{
+			{
+				SpanGuid: ptr("1c5994087c3bf8be"),
+				Message:  ptr("buffer full"),
+			},
+		},
+	}
+
+	res, err := initRequest(orig, nil).ToOtel(context.Background())
+	is.NoErr(err)
+
+	s := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(s.Events().Len(), 1)
+	is.Equal(s.Events().At(0).Name(), "exception")
+	is.Equal(s.Events().At(0).Timestamp().AsTime().UnixMicro(), int64(1722075128500000))
+	is.Equal(s.Status().Code(), ptrace.StatusCodeError)
+
+	is.Equal(res.OrphanLogRecords, int64(2))
+	is.Equal(res.Logs.LogRecordCount(), 3)
+	records := res.Logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+
+	lr := records.At(0)
+	is.Equal(lr.Body().Str(), "flushed earlier")
+	is.True(lr.TraceID().IsEmpty())
+	is.True(lr.SpanID().IsEmpty())
+	v, ok := lr.Attributes().Get("lightstep.span_guid")
+	is.True(ok)
+	is.Equal(v.Str(), "00000000000000aa")
+
+	lr = records.At(1)
+	is.Equal(lr.Body().Str(), "page loaded")
+	is.True(lr.SpanID().IsEmpty())
+
+	lr = records.At(2)
+	is.Equal(lr.Body().Str(), "buffer full")
+	is.Equal(lr.TraceID().String(), "0000000000000000a3ce929d0e0e4736")
+	is.Equal(lr.SpanID().String(), "1c5994087c3bf8be")
+}
+
+// transformBoth converts a span of the given string tags and, when set, a log of the given fields by both
+// the thrift and the protobuf transforms, returning the thrift and the protobuf span
+func transformBoth(is *is.I, config *lightstepCommon.TransformConfig, tags, fields map[string]string) (ptrace.Span, ptrace.Span) {
+	var attrs, logFields []*collectorthrift.KeyValue
+	var pbTags, pbFields []*pb.KeyValue
+	for k, v := range tags {
+		attrs = append(attrs, &collectorthrift.KeyValue{Key: k, Value: v})
+		pbTags = append(pbTags, &pb.KeyValue{Key: k, Value: &pb.KeyValue_StringValue{StringValue: []byte(v)}})
+	}
+	for k, v := range fields {
+		logFields = append(logFields, &collectorthrift.KeyValue{Key: k, Value: v})
+		pbFields = append(pbFields, &pb.KeyValue{Key: k, Value: &pb.KeyValue_StringValue{StringValue: []byte(v)}})
+	}
+	thriftSpan := &collectorthrift.SpanRecord{
+		SpanGuid:       ptr("0000000000000001"),
+		TraceGuid:      ptr("0000000000000001"),
+		OldestMicros:   ptr(int64(1718207928000000)),
+		YoungestMicros: ptr(int64(1718207928000000)),
+		Attributes:     attrs,
+	}
+	pbSpan := &pb.Span{
+		SpanContext:    &pb.SpanContext{TraceId: 1, SpanId: 1},
+		StartTimestamp: &timestamp.Timestamp{Seconds: 1718207928},
+		Tags:           pbTags,
+	}
+	if len(fields) > 0 {
+		thriftSpan.LogRecords = []*collectorthrift.LogRecord{{TimestampMicros: ptr(int64(1718207928000000)), Fields: logFields}}
+		pbSpan.Logs = []*pb.Log{{Timestamp: &timestamp.Timestamp{Seconds: 1718207928}, Fields: pbFields}}
+	}
+
+	tr := initRequest(&collectorthrift.ReportRequest{
+		Runtime:     &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{thriftSpan},
+	}, config)
+	thriftOtel, err := tr.ToOtel(context.Background())
+	is.NoErr(err)
+
+	pbOtel, err := lightstepPb.NewLightstepRequest(&pb.ReportRequest{
+		Reporter: &pb.Reporter{},
+		Spans:    []*pb.Span{pbSpan},
+	}, tr.telemetry, "pb", config).ToOtel(context.Background())
+	is.NoErr(err)
+
+	return thriftOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0),
+		pbOtel.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+}
+
+func TestTransformation_SpanKind(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config lightstepCommon.SpanKindConfig
+		tags   map[string]string
+		kind   ptrace.SpanKind
+	}{
+		{
+			name: "known kind",
+			tags: map[string]string{"span.kind": "server"},
+			kind: ptrace.SpanKindServer,
+		},
+		{
+			name:   "known kind always custom",
+			config: lightstepCommon.SpanKindConfig{CustomKind: lightstepCommon.CustomKindAlways, StripTag: true},
+			tags:   map[string]string{"span.kind": "server"},
+			kind:   ptrace.SpanKindServer,
+		},
+		{
+			name:   "unknown kind",
+			config: lightstepCommon.SpanKindConfig{CustomKind: lightstepCommon.CustomKindUnknown},
+			tags:   map[string]string{"span.kind": "gateway"},
+			kind:   ptrace.SpanKindUnspecified,
+		},
+		{
+			name:   "inferred client",
+			config: lightstepCommon.SpanKindConfig{Infer: true},
+			tags:   map[string]string{"http.method": "GET", "peer.service": "orders"},
+			kind:   ptrace.SpanKindClient,
+		},
+		{
+			name:   "http method only not inferred",
+			config: lightstepCommon.SpanKindConfig{Infer: true},
+			tags:   map[string]string{"http.method": "GET"},
+			kind:   ptrace.SpanKindUnspecified,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			thriftSpan, pbSpan := transformBoth(is, &lightstepCommon.TransformConfig{SpanKind: tc.config}, tc.tags, nil)
+			is.Equal(thriftSpan.Kind(), tc.kind)
+			is.Equal(pbSpan.Kind(), tc.kind)
+			is.Equal(thriftSpan.Attributes().AsRaw(), pbSpan.Attributes().AsRaw())
+		})
+	}
+}
+
+func TestTransformation_StatusRules(t *testing.T) {
+	config := lightstepCommon.StatusConfig{HTTP: true, GRPC: true, MessageTags: []string{"error.message"}}
+	for _, tc := range []struct {
+		name    string
+		config  lightstepCommon.StatusConfig
+		attrs   []*collectorthrift.KeyValue
+		code    ptrace.StatusCode
+		message string
+	}{
+		{
+			name:   "http server 5xx",
+			config: config,
+			attrs: []*collectorthrift.KeyValue{
+				{Key: "span.kind", Value: "server"},
+				{Key: "http.status_code", Value: "503"},
+				{Key: "error.message", Value: "backend unavailable"},
+			},
+			code:    ptrace.StatusCodeError,
+			message: "backend unavailable",
+		},
+		{
+			name:   "http server 5xx disabled",
+			config: lightstepCommon.StatusConfig{},
+			attrs: []*collectorthrift.KeyValue{
+				{Key: "span.kind", Value: "server"},
+				{Key: "http.status_code", Value: "503"},
+			},
+			code: ptrace.StatusCodeUnset,
+		},
+		{
+			name:   "grpc client unavailable",
+			config: config,
+			attrs: []*collectorthrift.KeyValue{
+				{Key: "span.kind", Value: "client"},
+				{Key: "grpc.status_code", Value: "UNAVAILABLE"},
+			},
+			code: ptrace.StatusCodeError,
+		},
+		{
+			name:   "grpc server not found",
+			config: config,
+			attrs: []*collectorthrift.KeyValue{
+				{Key: "span.kind", Value: "server"},
+				{Key: "grpc.status_code", Value: "5"},
+			},
+			code: ptrace.StatusCodeUnset,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			orig := &collectorthrift.ReportRequest{
+				Runtime: &collectorthrift.Runtime{},
+				SpanRecords: []*collectorthrift.SpanRecord{
+					{
+						SpanGuid:       ptr("1c5994087c3bf8be"),
+						TraceGuid:      ptr("a3ce929d0e0e4736"),
+						OldestMicros:   ptr(int64(1722075128000000)),
+						YoungestMicros: ptr(int64(1722075129000000)),
+						Attributes:     tc.attrs,
+					},
+				},
+			}
+
+			res, err := initRequest(orig, &lightstepCommon.TransformConfig{Status: tc.config}).ToOtel(context.Background())
+			is.NoErr(err)
+			s := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+			is.Equal(s.Status().Code(), tc.code)
+			is.Equal(s.Status().Message(), tc.message)
+		})
+	}
+}
+
+func TestTransformation_ErrorLogs(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				OldestMicros:   ptr(int64(1000)),
+				YoungestMicros: ptr(int64(2000)),
+				ErrorFlag:      ptr(true),
+				LogRecords: []*collectorthrift.LogRecord{
+					{
+						TimestampMicros: ptr(int64(1500)),
+						Message:         ptr("upstream timed out"),
+						Fields: []*collectorthrift.KeyValue{
+							{Key: "event", Value: "error"},
+							{Key: "error.kind", Value: "TimeoutError"},
+							{Key: "stack", Value: "at fetch (app.js:42)"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	res, err := initRequest(orig, nil).ToOtel(context.Background())
+	is.NoErr(err)
+
+	s := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(s.Status().Code(), ptrace.StatusCodeError)
+	is.Equal(s.Status().Message(), "upstream timed out")
+
+	ev := s.Events().At(0)
+	is.Equal(ev.Name(), "exception")
+	for key, expected := range map[string]string{
+		"exception.type":       "TimeoutError",
+		"exception.message":    "upstream timed out",
+		"exception.stacktrace": "at fetch (app.js:42)",
+	} {
+		v, ok := ev.Attributes().Get(key)
+		is.True(ok)
+		is.Equal(v.Str(), expected)
+	}
+}
+
+func TestTransformation_Validation(t *testing.T) {
+	is := is.New(t)
+	orig := &collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				SpanName:       ptr("end-before-start"),
+				OldestMicros:   ptr(int64(2000)),
+				YoungestMicros: ptr(int64(1000)),
+			},
+			{
+				SpanGuid:       ptr("0000000000000000"),
+				TraceGuid:      ptr("a3ce929d0e0e4736"),
+				SpanName:       ptr("zero-span-id"),
+				OldestMicros:   ptr(int64(1000)),
+				YoungestMicros: ptr(int64(2000)),
+			},
+		},
+	}
+
+	tr := initRequest(orig, &lightstepCommon.TransformConfig{
+		Validation: lightstepCommon.ValidationConfig{
+			EndBeforeStart: lightstepCommon.ValidationActionRepair,
+			ZeroSpanID:     lightstepCommon.ValidationActionDrop,
+		},
+	})
+	res, err := tr.ToOtel(context.Background())
+	is.NoErr(err)
+
+	spans := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	is.Equal(spans.Len(), 1)
+	is.Equal(spans.At(0).Name(), "end-before-start")
+	is.Equal(spans.At(0).EndTimestamp(), spans.At(0).StartTimestamp())
+	is.Equal(tr.telemetry.InvalidSpans[string(lightstepCommon.RuleEndBeforeStart)], int64(1))
+	is.Equal(tr.telemetry.InvalidSpans[string(lightstepCommon.RuleZeroSpanID)], int64(1))
+}
+
+func TestTransformation_NilReportRequest(t *testing.T) {
+	is := is.New(t)
+	_, err := initRequest(nil, nil).ToOtel(context.Background())
+	is.Equal(err, lightstepCommon.ErrNoReportRequest)
+}
+
+func TestTransformation_NilRuntime(t *testing.T) {
+	is := is.New(t)
+	res, err := initRequest(&collectorthrift.ReportRequest{
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{SpanGuid: ptr("1c5994087c3bf8be"), OldestMicros: ptr(int64(1000)), YoungestMicros: ptr(int64(2000))},
+		},
+	}, nil).ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(res.ServiceName, "")
+	is.Equal(res.SpanCount(), 1)
+}
+
+func TestTransformation_NilTimestamps(t *testing.T) {
+	is := is.New(t)
+	res, err := initRequest(&collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			{
+				SpanGuid: ptr("1c5994087c3bf8be"),
+				LogRecords: []*collectorthrift.LogRecord{
+					{Message: ptr("no timestamp")},
+				},
+			},
+		},
+	}, nil).ToOtel(context.Background())
+	is.NoErr(err)
+
+	s := res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	is.Equal(s.StartTimestamp(), pcommon.Timestamp(0))
+	is.Equal(s.EndTimestamp(), pcommon.Timestamp(0))
+	is.Equal(s.Events().At(0).Timestamp(), pcommon.Timestamp(0))
+}
+
+func TestTransformation_NilElements(t *testing.T) {
+	is := is.New(t)
+	res, err := initRequest(&collectorthrift.ReportRequest{
+		Runtime: &collectorthrift.Runtime{
+			Attrs: []*collectorthrift.KeyValue{nil, {Key: "lightstep.component_name", Value: "svc"}},
+		},
+		SpanRecords: []*collectorthrift.SpanRecord{
+			nil,
+			{
+				SpanGuid:       ptr("1c5994087c3bf8be"),
+				OldestMicros:   ptr(int64(1000)),
+				YoungestMicros: ptr(int64(2000)),
+				Attributes:     []*collectorthrift.KeyValue{nil},
+				JoinIds:        []*collectorthrift.TraceJoinId{nil},
+				LogRecords:     []*collectorthrift.LogRecord{nil, {Fields: []*collectorthrift.KeyValue{nil}}},
+			},
+		},
+		LogRecords:      []*collectorthrift.LogRecord{nil},
+		InternalLogs:    []*collectorthrift.LogRecord{nil},
+		Counters:        []*collectorthrift.NamedCounter{nil},
+		InternalMetrics: &collectorthrift.Metrics{Counts: []*collectorthrift.MetricsSample{nil}},
+	}, nil).ToOtel(context.Background())
+	is.NoErr(err)
+	is.Equal(res.ServiceName, "svc")
+	is.Equal(res.SpanCount(), 1)
+	is.Equal(res.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Events().Len(), 1)
+}
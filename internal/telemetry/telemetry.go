@@ -2,6 +2,8 @@ package telemetry
 
 import (
 	"context"
+	"sync"
+
 	"go.opentelemetry.io/collector/receiver"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
@@ -13,11 +15,19 @@ import (
 type Telemetry struct {
 	Name              string
 	NonUTF8Attributes map[string]int64
+	DroppedLogRecords map[string]int64
+	InvalidSpans      map[string]int64
+
+	// mu guards the counter maps above, the Increment* methods are called
+	// from concurrent request handlers
+	mu sync.Mutex
 
 	_requestsProcessed  metric.Int64Counter
 	_requestsFailed     metric.Int64Counter
 	_nonUTF8Attributes  metric.Int64Counter
 	_clientSpansDropped metric.Int64Counter
+	_logRecordsDropped  metric.Int64Counter
+	_invalidSpans       metric.Int64Counter
 
 	Logger *zap.Logger
 	Tracer trace.Tracer
@@ -49,6 +59,8 @@ func (t *Telemetry) Init(set receiver.Settings) {
 		t.Tracer = set.TracerProvider.Tracer(t.Name)
 	}
 	t.NonUTF8Attributes = make(map[string]int64)
+	t.DroppedLogRecords = make(map[string]int64)
+	t.InvalidSpans = make(map[string]int64)
 
 	t.Logger = set.Logger
 
@@ -93,6 +105,24 @@ func (t *Telemetry) Init(set receiver.Settings) {
 		metric.WithUnit("1"),
 	)
 	t.logError(err, name)
+
+	name = "lightstep_receiver_log_records_dropped"
+	description = "Number of report log records not attached to spans dropped without logs pipeline"
+	t._logRecordsDropped, err = meter.Int64Counter(
+		name,
+		metric.WithDescription(description),
+		metric.WithUnit("1"),
+	)
+	t.logError(err, name)
+
+	name = "lightstep_receiver_invalid_spans"
+	description = "Number of spans breaking validation rules"
+	t._invalidSpans, err = meter.Int64Counter(
+		name,
+		metric.WithDescription(description),
+		metric.WithUnit("1"),
+	)
+	t.logError(err, name)
 }
 
 func (t *Telemetry) IncrementClientDropSpans(serviceName string, value int64) {
@@ -141,11 +171,9 @@ func (t *Telemetry) IncrementFailed(transport string, value int64) {
 }
 
 func (t *Telemetry) IncrementNonUTF8Attributes(transport string, value int64) {
-	if _, ok := t.NonUTF8Attributes[transport]; !ok {
-		t.NonUTF8Attributes[transport] = value
-	} else {
-		t.NonUTF8Attributes[transport]++
-	}
+	t.mu.Lock()
+	t.NonUTF8Attributes[transport] += value
+	t.mu.Unlock()
 
 	if t._nonUTF8Attributes == nil {
 		return
@@ -160,3 +188,42 @@ func (t *Telemetry) IncrementNonUTF8Attributes(transport string, value int64) {
 		),
 	)
 }
+
+func (t *Telemetry) IncrementDroppedLogRecords(transport string, value int64) {
+	t.mu.Lock()
+	t.DroppedLogRecords[transport] += value
+	t.mu.Unlock()
+
+	if t._logRecordsDropped == nil {
+		return
+	}
+	t._logRecordsDropped.Add(
+		context.Background(),
+		value,
+		metric.WithAttributeSet(
+			attribute.NewSet(
+				attribute.String("transport", transport),
+			),
+		),
+	)
+}
+
+func (t *Telemetry) IncrementInvalidSpans(rule string, serviceName string, value int64) {
+	t.mu.Lock()
+	t.InvalidSpans[rule] += value
+	t.mu.Unlock()
+
+	if t._invalidSpans == nil {
+		return
+	}
+	t._invalidSpans.Add(
+		context.Background(),
+		value,
+		metric.WithAttributeSet(
+			attribute.NewSet(
+				attribute.String("rule", rule),
+				attribute.String("for.service.name", serviceName),
+			),
+		),
+	)
+}
@@ -3,6 +3,8 @@ package lightstepreceiver
 import (
 	"context"
 	"fmt"
+	"sync"
+
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/receiver"
@@ -24,7 +26,9 @@ type lightstepReceiver struct {
 	serverPbHTTP *http.ServerHTTP
 	serverThrift *lightstep_thrift.ThriftServer
 
-	nextTraces consumer.Traces
+	nextTraces  consumer.Traces
+	nextMetrics consumer.Metrics
+	nextLogs    consumer.Logs
 
 	logger   *zap.Logger
 	settings *receiver.Settings
@@ -35,12 +39,34 @@ type lightstepReceiver struct {
 	obsrepThrift *receiverhelper.ObsReport
 
 	telemetry *telemetry.Telemetry
+
+	startOnce    sync.Once
+	shutdownOnce sync.Once
 }
 
+// Start creates and starts the servers once for all the signals sharing the receiver
 func (r *lightstepReceiver) Start(ctx context.Context, host component.Host) error {
+	var err error
+	r.startOnce.Do(func() {
+		err = r.start(ctx, host)
+	})
+	return err
+}
+
+func (r *lightstepReceiver) start(ctx context.Context, host component.Host) error {
 	r.logger.Info("starting servers")
 	var err error
 
+	if r.cfg.PbGrpc != nil {
+		r.serverGRPC = grpc.NewServer(r.cfg.PbGrpc, &r.cfg.TransformConfig, r.settings, r.logger, r.nextTraces, r.nextMetrics, r.nextLogs, r.obsrepGRPC, r.telemetry)
+	}
+	if r.cfg.PbHTTP != nil {
+		r.serverPbHTTP = http.NewServer(r.cfg.PbHTTP, &r.cfg.TransformConfig, r.settings, r.nextTraces, r.nextMetrics, r.nextLogs, r.obsrepPbHTTP, r.telemetry)
+	}
+	if r.cfg.Thrift != nil {
+		r.serverThrift = lightstep_thrift.NewServer(r.cfg.Thrift, &r.cfg.TransformConfig, r.settings, r.nextTraces, r.nextMetrics, r.nextLogs, r.obsrepThrift, r.telemetry)
+	}
+
 	if r.serverGRPC != nil {
 		if err = r.serverGRPC.Start(host); err != nil {
 			r.telemetry.Logger.Error("can't start grpc server", zap.Error(err))
@@ -66,8 +92,16 @@ func (r *lightstepReceiver) Start(ctx context.Context, host component.Host) erro
 	return nil
 }
 
+// Shutdown stops the servers once for all the signals sharing the receiver
 func (r *lightstepReceiver) Shutdown(ctx context.Context) error {
-	var errs error
+	r.shutdownOnce.Do(func() {
+		receivers.remove(r.cfg)
+		r.shutdown(ctx)
+	})
+	return nil
+}
+
+func (r *lightstepReceiver) shutdown(ctx context.Context) {
 	r.logger.Info("shutting down server")
 
 	if r.serverGRPC != nil {
@@ -81,11 +115,9 @@ func (r *lightstepReceiver) Shutdown(ctx context.Context) error {
 	if r.serverThrift != nil {
 		r.serverThrift.Shutdown(ctx)
 	}
-
-	return errs
 }
 
-func newLightstepReceiver(cfg *Config, set *receiver.Settings, nextTraces consumer.Traces) (*lightstepReceiver, error) {
+func newLightstepReceiver(cfg *Config, set *receiver.Settings) (*lightstepReceiver, error) {
 	var err error
 
 	r := &lightstepReceiver{
@@ -93,7 +125,6 @@ func newLightstepReceiver(cfg *Config, set *receiver.Settings, nextTraces consum
 		serverGRPC:   nil,
 		serverPbHTTP: nil,
 		serverThrift: nil,
-		nextTraces:   nextTraces,
 		settings:     set,
 		logger:       nil,
 		tracer:       set.TracerProvider.Tracer(metadata.Type.String()),
@@ -114,7 +145,6 @@ func newLightstepReceiver(cfg *Config, set *receiver.Settings, nextTraces consum
 		if err != nil {
 			return nil, fmt.Errorf("can't init telemetry: %s", err)
 		}
-		r.serverGRPC = grpc.NewServer(cfg.PbGrpc, set, r.logger, nextTraces, r.obsrepGRPC, r.telemetry)
 	}
 
 	if cfg.PbHTTP != nil {
@@ -126,7 +156,6 @@ func newLightstepReceiver(cfg *Config, set *receiver.Settings, nextTraces consum
 		if err != nil {
 			return nil, fmt.Errorf("can't init telemetry: %s", err)
 		}
-		r.serverPbHTTP = http.NewServer(cfg.PbHTTP, set, nextTraces, r.obsrepPbHTTP, r.telemetry)
 	}
 
 	if cfg.Thrift != nil {
@@ -138,8 +167,37 @@ func newLightstepReceiver(cfg *Config, set *receiver.Settings, nextTraces consum
 		if err != nil {
 			return nil, fmt.Errorf("can't init telemetry: %s", err)
 		}
-		r.serverThrift = lightstep_thrift.NewServer(cfg.Thrift, set, nextTraces, r.obsrepThrift, r.telemetry)
 	}
 
 	return r, nil
 }
+
+// sharedReceivers keeps receivers by config, so all the signals of one receiver share its servers
+type sharedReceivers struct {
+	mu        sync.Mutex
+	receivers map[*Config]*lightstepReceiver
+}
+
+var receivers = &sharedReceivers{receivers: map[*Config]*lightstepReceiver{}}
+
+func (s *sharedReceivers) getOrCreate(cfg *Config, set *receiver.Settings) (*lightstepReceiver, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.receivers[cfg]; ok {
+		return r, nil
+	}
+	r, err := newLightstepReceiver(cfg, set)
+	if err != nil {
+		return nil, err
+	}
+	s.receivers[cfg] = r
+	return r, nil
+}
+
+func (s *sharedReceivers) remove(cfg *Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.receivers, cfg)
+}